@@ -0,0 +1,98 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ACL restricts which peers may send messages and negotiate. Deny takes
+// precedence: an ID on both lists is blocked. When Allow is non-empty only
+// the IDs on it are admitted, so senders that do not identify themselves
+// are blocked too; with only a Deny list they are admitted. The zero value
+// admits everyone.
+type ACL struct {
+	Allow []string `json:"allow"`
+	Deny  []string `json:"deny"`
+}
+
+// Check returns why id is blocked, or nil when it may talk to the node.
+func (a ACL) Check(id string) error {
+	for _, d := range a.Deny {
+		if d == id {
+			return fmt.Errorf("service %q is on the denylist", id)
+		}
+	}
+	if len(a.Allow) == 0 {
+		return nil
+	}
+	for _, al := range a.Allow {
+		if al == id {
+			return nil
+		}
+	}
+	if id == "" {
+		return fmt.Errorf("an allowlist is configured and the sender did not identify itself")
+	}
+	return fmt.Errorf("service %q is not on the allowlist", id)
+}
+
+// checkACL applies the current ACL to sender.
+func (s *Server) checkACL(sender string) *messageError {
+	err := s.acl.Load().Check(sender)
+	if err == nil {
+		return nil
+	}
+	return &messageError{status: http.StatusForbidden, fields: map[string]string{
+		"error":  "sender not allowed",
+		"reason": err.Error(),
+	}}
+}
+
+// SetACL replaces the ACL applied to later requests.
+func (s *Server) SetACL(a ACL) {
+	s.acl.Store(&a)
+}
+
+// aclHandler serves the current ACL on GET and replaces it on POST. It
+// requires the configured admin token as a bearer token and is not served
+// at all when no token is configured.
+func (s *Server) aclHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.authorizeAdmin(w, r) {
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, s.acl.Load())
+	case http.MethodPost:
+		var a ACL
+		if err := json.NewDecoder(r.Body).Decode(&a); err != nil {
+			writeBodyError(w, "invalid acl body", err)
+			return
+		}
+		s.SetACL(a)
+		s.log.Info("acl updated", "allow", a.Allow, "deny", a.Deny)
+		writeJSON(w, http.StatusOK, a)
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		writeError(w, http.StatusMethodNotAllowed, "acl requires GET or POST")
+	}
+}
+
+// authorizeAdmin reports whether r carries the admin bearer token. If not,
+// it has answered 401, or 404 when no token is configured.
+func (s *Server) authorizeAdmin(w http.ResponseWriter, r *http.Request) bool {
+	if len(s.cfg.AdminToken) == 0 {
+		http.NotFound(w, r)
+		return false
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(token), s.cfg.AdminToken) != 1 {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		writeError(w, http.StatusUnauthorized, "admin token required")
+		return false
+	}
+	return true
+}
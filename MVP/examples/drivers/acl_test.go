@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestACLCheck(t *testing.T) {
+	both := ACL{Allow: []string{"rust-service", "python-service"}, Deny: []string{"python-service"}}
+	tests := []struct {
+		acl     ACL
+		id      string
+		allowed bool
+	}{
+		{ACL{}, "anyone", true},
+		{ACL{}, "", true},
+		{ACL{Allow: []string{"rust-service"}}, "rust-service", true},
+		{ACL{Allow: []string{"rust-service"}}, "unknown-service", false},
+		{ACL{Allow: []string{"rust-service"}}, "", false},
+		{ACL{Deny: []string{"rust-service"}}, "rust-service", false},
+		{ACL{Deny: []string{"rust-service"}}, "unknown-service", true},
+		{ACL{Deny: []string{"rust-service"}}, "", true},
+		// Deny wins over allow.
+		{both, "python-service", false},
+		{both, "rust-service", true},
+		{both, "unknown-service", false},
+	}
+	for _, tt := range tests {
+		if err := tt.acl.Check(tt.id); (err == nil) != tt.allowed {
+			t.Errorf("%+v.Check(%q) = %v, want allowed %v", tt.acl, tt.id, err, tt.allowed)
+		}
+	}
+}
+
+func TestACLBlocksMessagesAndNegotiation(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ACL = ACL{Allow: []string{"rust-service"}}
+	h := NewServer(cfg, WithLogOutput(io.Discard)).Routes()
+	for _, path := range []string{"/message", "/negotiate"} {
+		for id, want := range map[string]int{"rust-service": http.StatusOK, "python-service": http.StatusForbidden} {
+			body := `{"service_id":"` + id + `","version":"v1.stable.0.stable.0.stable","timestamp":1,"constraint":"^v1.stable.0.stable.0.stable"}`
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, newMessageRequest(path, body))
+			if rec.Code != want {
+				t.Errorf("%s from %s: status = %d, want %d", path, id, rec.Code, want)
+			}
+			if want == http.StatusForbidden {
+				var fields map[string]string
+				json.NewDecoder(rec.Body).Decode(&fields)
+				if fields["reason"] != `service "python-service" is not on the allowlist` {
+					t.Errorf("%s from %s: body = %v", path, id, fields)
+				}
+			}
+		}
+	}
+}
+
+func TestACLAdminReload(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.AdminToken = []byte("s3cret")
+	srv := NewServer(cfg, WithLogOutput(io.Discard))
+	h := srv.Routes()
+	admin := func(token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/admin/acl", strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := admin("", `{"deny":["rust-service"]}`); rec.Code != http.StatusUnauthorized {
+		t.Errorf("no token: status = %d", rec.Code)
+	}
+	if rec := admin("wrong", `{"deny":["rust-service"]}`); rec.Code != http.StatusUnauthorized {
+		t.Errorf("wrong token: status = %d", rec.Code)
+	}
+	if err := srv.acl.Load().Check("rust-service"); err != nil {
+		t.Fatalf("unauthorized request changed the ACL: %v", err)
+	}
+	if rec := admin("s3cret", `{"deny":["rust-service"]}`); rec.Code != http.StatusOK {
+		t.Fatalf("reload: status = %d, body = %s", rec.Code, rec.Body)
+	}
+	rec := postMessage(t, srv, `{"service_id":"rust-service","version":"v1.stable.0.stable.0.stable","timestamp":1}`)
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("denied sender after reload: status = %d", rec.Code)
+	}
+	if rec := admin("s3cret", `{"deny":`); rec.Code != http.StatusBadRequest {
+		t.Errorf("malformed body: status = %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	NewServer(DefaultConfig(), WithLogOutput(io.Discard)).Routes().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/admin/acl", strings.NewReader(`{}`)))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("without an admin token: status = %d, want 404", rec.Code)
+	}
+}
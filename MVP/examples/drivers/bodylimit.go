@@ -0,0 +1,35 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// withMaxBody caps the request body at cfg.MaxBodyBytes. Reads past the
+// limit fail with *http.MaxBytesError, which writeBodyError turns into 413.
+func (s *Server) withMaxBody(next http.Handler) http.Handler {
+	if s.cfg.MaxBodyBytes <= 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.Body = http.MaxBytesReader(w, r.Body, s.cfg.MaxBodyBytes)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// writeBodyError reports a failure to read or decode the request body:
+// 413 when the body exceeded the size limit, 400 otherwise.
+//
+// After a 413 the rest of the body is still unread, so the connection is
+// closed rather than reused. http.MaxBytesReader would arrange that itself,
+// but cannot see through the middleware's wrapped ResponseWriter.
+func writeBodyError(w http.ResponseWriter, what string, err error) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		w.Header().Set("Connection", "close")
+		writeError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("request body exceeds %d bytes", tooLarge.Limit))
+		return
+	}
+	writeError(w, http.StatusBadRequest, fmt.Sprintf("%s: %v", what, err))
+}
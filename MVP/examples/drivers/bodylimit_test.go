@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func oversizedMessage(n int) string {
+	return `{"service_id":"rust-service","version":"v1.stable.0.stable.0.stable","timestamp":1,"payload":"` +
+		strings.Repeat("x", n) + `"}`
+}
+
+func TestMaxBodyOverHTTP(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.MaxBodyBytes = 1024
+	ts := httptest.NewServer(NewServer(cfg, WithLogOutput(io.Discard)).Routes())
+	defer ts.Close()
+
+	resp, err := postServiceMessage(ts.URL+"/message", oversizedMessage(64<<10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var body map[string]string
+	err = json.NewDecoder(resp.Body).Decode(&body)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusRequestEntityTooLarge || body["error"] != "request body exceeds 1024 bytes" {
+		t.Errorf("status = %d, body = %v", resp.StatusCode, body)
+	}
+	// The unread remainder must not be parsed as the next request, so the
+	// server has to close the connection.
+	if !resp.Close {
+		t.Error("connection left open after an oversized body")
+	}
+
+	resp, err = postServiceMessage(ts.URL+"/message", oversizedMessage(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("small message after an oversized one: status = %d", resp.StatusCode)
+	}
+}
+
+func TestMaxBodyRoutes(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.MaxBodyBytes = 64
+	h := NewServer(cfg, WithLogOutput(io.Discard)).Routes()
+	for path, body := range map[string]string{
+		"/message":        oversizedMessage(100),
+		"/messages/batch": "[" + oversizedMessage(100) + "]",
+		"/negotiate":      `{"service_id":"rust-service","constraint":"` + strings.Repeat(" ", 100) + `"}`,
+	} {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", mediaJSON)
+		req.Header.Set("X-Service-ID", "rust-service")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != http.StatusRequestEntityTooLarge {
+			t.Errorf("%s: status = %d, want 413", path, rec.Code)
+		}
+	}
+
+	cfg.MaxBodyBytes = 0
+	rec := httptest.NewRecorder()
+	NewServer(cfg, WithLogOutput(io.Discard)).Routes().ServeHTTP(rec, newMessageRequest("/message", oversizedMessage(100)))
+	if rec.Code != http.StatusOK {
+		t.Errorf("limit disabled: status = %d", rec.Code)
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"sync"
+	"sync/atomic"
+
+	"github.com/obinexus/rust-semverx/MVP/examples/drivers/semverx"
+)
+
+// BusFilter selects the messages a subscriber receives. The zero value
+// matches everything.
+type BusFilter struct {
+	// ServiceID is a path.Match pattern such as "rust-*" matched against
+	// the sender. Empty matches every sender; a malformed pattern matches
+	// none.
+	ServiceID string
+	// MinChannel drops messages whose least stable component ranks below
+	// it. ChannelUnknown disables the check.
+	MinChannel semverx.Channel
+}
+
+func (f BusFilter) matches(msg ServiceMessage) bool {
+	if f.ServiceID != "" {
+		if ok, err := path.Match(f.ServiceID, msg.ServiceID); !ok || err != nil {
+			return false
+		}
+	}
+	return msg.Version.LowestChannel().Rank() >= f.MinChannel.Rank()
+}
+
+// OverflowPolicy decides what Publish does when a subscriber's buffer is
+// full.
+type OverflowPolicy int
+
+const (
+	// OverflowDrop discards the message for that subscriber only.
+	OverflowDrop OverflowPolicy = iota
+	// OverflowBlock waits until the subscriber makes room or unsubscribes,
+	// holding up the publisher and so the request being handled.
+	OverflowBlock
+)
+
+// ParseOverflowPolicy accepts "drop" or "block".
+func ParseOverflowPolicy(s string) (OverflowPolicy, error) {
+	switch s {
+	case "drop":
+		return OverflowDrop, nil
+	case "block":
+		return OverflowBlock, nil
+	}
+	return 0, fmt.Errorf("unknown overflow policy %q, want drop or block", s)
+}
+
+func (p OverflowPolicy) String() string {
+	if p == OverflowBlock {
+		return "block"
+	}
+	return "drop"
+}
+
+// Bus fans accepted messages out to in-process subscribers.
+type Bus struct {
+	buffer  int
+	policy  OverflowPolicy
+	dropped atomic.Uint64
+
+	mu   sync.RWMutex
+	subs map[*subscription]struct{}
+}
+
+type subscription struct {
+	filter BusFilter
+	ch     chan ServiceMessage
+	done   chan struct{}
+}
+
+// NewBus returns a Bus giving each subscriber a buffer of the given size.
+func NewBus(buffer int, policy OverflowPolicy) *Bus {
+	return &Bus{buffer: buffer, policy: policy, subs: make(map[*subscription]struct{})}
+}
+
+// Subscribe registers a subscriber for messages matching f. The returned
+// function unsubscribes and closes the channel; it is safe to call more
+// than once.
+func (b *Bus) Subscribe(f BusFilter) (<-chan ServiceMessage, func()) {
+	sub := &subscription{filter: f, ch: make(chan ServiceMessage, b.buffer), done: make(chan struct{})}
+	b.mu.Lock()
+	b.subs[sub] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	return sub.ch, func() {
+		once.Do(func() {
+			// Release a publisher blocked on this subscriber before
+			// waiting for the write lock.
+			close(sub.done)
+			b.mu.Lock()
+			delete(b.subs, sub)
+			b.mu.Unlock()
+			close(sub.ch)
+		})
+	}
+}
+
+// Publish delivers msg to every matching subscriber according to the
+// bus's OverflowPolicy.
+func (b *Bus) Publish(msg ServiceMessage) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for sub := range b.subs {
+		if !sub.filter.matches(msg) {
+			continue
+		}
+		if b.policy == OverflowBlock {
+			select {
+			case sub.ch <- msg:
+			case <-sub.done:
+			}
+			continue
+		}
+		select {
+		case sub.ch <- msg:
+		default:
+			b.dropped.Add(1)
+		}
+	}
+}
+
+// Dropped returns how many deliveries OverflowDrop has discarded.
+func (b *Bus) Dropped() uint64 {
+	return b.dropped.Load()
+}
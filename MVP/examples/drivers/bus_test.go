@@ -0,0 +1,105 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/obinexus/rust-semverx/MVP/examples/drivers/semverx"
+)
+
+func busMessage(id, version string) ServiceMessage {
+	return ServiceMessage{ServiceID: id, Version: semverx.MustParseVersion(version), Timestamp: 1}
+}
+
+// drain returns the service IDs currently buffered on ch.
+func drain(ch <-chan ServiceMessage) []string {
+	var ids []string
+	for {
+		select {
+		case msg := <-ch:
+			ids = append(ids, msg.ServiceID)
+		default:
+			return ids
+		}
+	}
+}
+
+func TestBusFanOutAndFilters(t *testing.T) {
+	b := NewBus(8, OverflowDrop)
+	all, cancelAll := b.Subscribe(BusFilter{})
+	defer cancelAll()
+	rust, cancelRust := b.Subscribe(BusFilter{ServiceID: "rust-*"})
+	defer cancelRust()
+	stable, cancelStable := b.Subscribe(BusFilter{MinChannel: semverx.ChannelStable})
+	defer cancelStable()
+	bad, cancelBad := b.Subscribe(BusFilter{ServiceID: "["})
+	defer cancelBad()
+
+	b.Publish(busMessage("rust-service", "v1.stable.0.stable.0.stable"))
+	b.Publish(busMessage("rust-edge", "v1.stable.0.beta.0.stable"))
+	b.Publish(busMessage("python-service", "v1.stable.0.stable.0.stable"))
+
+	for name, tc := range map[string]struct {
+		ch   <-chan ServiceMessage
+		want string
+	}{
+		"all":    {all, "rust-service rust-edge python-service"},
+		"rust":   {rust, "rust-service rust-edge"},
+		"stable": {stable, "rust-service python-service"},
+		"bad":    {bad, ""},
+	} {
+		got := drain(tc.ch)
+		if s := strings.Join(got, " "); s != tc.want {
+			t.Errorf("%s received %q, want %q", name, s, tc.want)
+		}
+	}
+}
+
+func TestBusDropPolicy(t *testing.T) {
+	b := NewBus(1, OverflowDrop)
+	ch, cancel := b.Subscribe(BusFilter{})
+	defer cancel()
+	b.Publish(busMessage("a", "v1.stable.0.stable.0.stable"))
+	b.Publish(busMessage("b", "v1.stable.0.stable.0.stable"))
+	if got := drain(ch); len(got) != 1 || got[0] != "a" || b.Dropped() != 1 {
+		t.Errorf("received %v, dropped %d; want [a], 1", got, b.Dropped())
+	}
+}
+
+func TestBusBlockPolicy(t *testing.T) {
+	b := NewBus(0, OverflowBlock)
+	ch, cancel := b.Subscribe(BusFilter{})
+	done := make(chan struct{})
+	go func() {
+		b.Publish(busMessage("a", "v1.stable.0.stable.0.stable"))
+		b.Publish(busMessage("b", "v1.stable.0.stable.0.stable"))
+		close(done)
+	}()
+	if msg := <-ch; msg.ServiceID != "a" {
+		t.Fatalf("got %s, want a", msg.ServiceID)
+	}
+	select {
+	case <-done:
+		t.Fatal("Publish did not block on a full subscriber")
+	case <-time.After(20 * time.Millisecond):
+	}
+	// Unsubscribing releases the blocked publisher and closes the channel.
+	cancel()
+	<-done
+	if _, ok := <-ch; ok {
+		t.Error("channel still open after unsubscribe")
+	}
+	cancel()
+}
+
+func TestMessageHandlerPublishes(t *testing.T) {
+	srv := newTestServer()
+	ch, cancel := srv.Bus().Subscribe(BusFilter{ServiceID: "rust-service"})
+	defer cancel()
+	postMessage(t, srv, `{"service_id":"rust-service","version":"v1.stable.0.stable.0.stable","timestamp":1}`)
+	postMessage(t, srv, `{"service_id":"rust-service","version":"v2.stable.0.stable.0.stable","timestamp":1}`)
+	if got := drain(ch); len(got) != 1 {
+		t.Errorf("bus received %d messages, want only the accepted one", len(got))
+	}
+}
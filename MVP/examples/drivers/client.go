@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// Backoff controls how Client.Send spaces out retries. The n-th retry waits
+// Initial*Multiplier^(n-1), capped at Max, scaled by a random factor in
+// [1-Jitter, 1+Jitter].
+type Backoff struct {
+	Initial    time.Duration
+	Max        time.Duration
+	Multiplier float64
+	Jitter     float64
+	// MaxAttempts caps the total number of requests, including the first.
+	MaxAttempts int
+}
+
+// DefaultBackoff is the Backoff used by NewClient when none is given.
+var DefaultBackoff = Backoff{
+	Initial:     100 * time.Millisecond,
+	Max:         5 * time.Second,
+	Multiplier:  2,
+	Jitter:      0.2,
+	MaxAttempts: 5,
+}
+
+// delay returns the wait before retry n (n >= 1).
+func (b Backoff) delay(n int, rnd func() float64) time.Duration {
+	d := float64(b.Initial)
+	for i := 1; i < n && d < float64(b.Max); i++ {
+		d *= b.Multiplier
+	}
+	if b.Max > 0 && d > float64(b.Max) {
+		d = float64(b.Max)
+	}
+	if b.Jitter > 0 {
+		d *= 1 + b.Jitter*(2*rnd()-1)
+	}
+	return time.Duration(d)
+}
+
+// StatusError is a non-2xx response from a peer.
+type StatusError struct {
+	StatusCode int
+	Status     string
+}
+
+func (e *StatusError) Error() string { return "peer responded " + e.Status }
+
+// retryable reports whether the status may succeed on a later attempt.
+func (e *StatusError) retryable() bool {
+	return retryableStatus(e.StatusCode)
+}
+
+// retryableStatus reports whether Client.Send retries a response with code.
+func retryableStatus(code int) bool {
+	return code >= 500 || code == http.StatusRequestTimeout || code == http.StatusTooManyRequests
+}
+
+// Client sends messages to peers, retrying transient failures.
+type Client struct {
+	HTTP    *http.Client
+	Backoff Backoff
+	// Timeout bounds each attempt. Zero relies on ctx alone.
+	Timeout time.Duration
+
+	rand func() float64
+}
+
+// NewClient returns a Client using hc, or http.DefaultClient when hc is nil,
+// and DefaultBackoff.
+func NewClient(hc *http.Client) *Client {
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	return &Client{HTTP: hc, Backoff: DefaultBackoff, rand: rand.Float64}
+}
+
+// Send posts msg to the /message endpoint of the peer at peerURL. Transport
+// errors, timeouts and 5xx, 408 and 429 responses are retried with
+// exponential backoff up to Backoff.MaxAttempts; any other non-2xx status
+// fails immediately with a *StatusError. Cancelling ctx stops retrying.
+// Every attempt carries the same Idempotency-Key, so a peer that processed
+// an attempt whose response was lost does not process the retry again.
+func (c *Client) Send(ctx context.Context, peerURL string, msg ServiceMessage) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	key := newRequestID()
+	rnd := c.rand
+	if rnd == nil {
+		rnd = rand.Float64
+	}
+	attempts := c.Backoff.MaxAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+	for n := 1; ; n++ {
+		err = c.attempt(ctx, peerURL+"/message", msg.ServiceID, key, body)
+		if err == nil {
+			return nil
+		}
+		var se *StatusError
+		if errors.As(err, &se) && !se.retryable() {
+			return err
+		}
+		if ctx.Err() != nil || n == attempts {
+			return fmt.Errorf("send to %s failed after %d attempts: %w", peerURL, n, err)
+		}
+		t := time.NewTimer(c.Backoff.delay(n, rnd))
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return fmt.Errorf("send to %s failed after %d attempts: %w", peerURL, n, err)
+		case <-t.C:
+		}
+	}
+}
+
+func (c *Client) attempt(ctx context.Context, url, sender, key string, body []byte) error {
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(idempotencyKeyHeader, key)
+	req.Header.Set(serviceIDHeader, sender)
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return &StatusError{StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/obinexus/rust-semverx/MVP/examples/drivers/semverx"
+)
+
+var testBackoff = Backoff{Initial: time.Millisecond, Max: 4 * time.Millisecond, Multiplier: 2, MaxAttempts: 4}
+
+// flakyPeer answers failStatus to the first failures requests and 200
+// afterwards, counting every request.
+func flakyPeer(t *testing.T, failures int32, failStatus int) (*httptest.Server, *int32) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/message" {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		if atomic.AddInt32(&calls, 1) <= failures {
+			w.WriteHeader(failStatus)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func testClient() *Client {
+	c := NewClient(nil)
+	c.Backoff = testBackoff
+	return c
+}
+
+func testSendMessage() ServiceMessage {
+	return ServiceMessage{ServiceID: "a", Version: semverx.MustParseVersion("v1.stable.0.stable.0.stable"), Timestamp: 1}
+}
+
+func TestClientRetriesUntilSuccess(t *testing.T) {
+	srv, calls := flakyPeer(t, 2, http.StatusServiceUnavailable)
+	if err := testClient().Send(context.Background(), srv.URL, testSendMessage()); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(calls); n != 3 {
+		t.Errorf("attempts = %d, want 3", n)
+	}
+}
+
+func TestClientGivesUpAfterMaxAttempts(t *testing.T) {
+	srv, calls := flakyPeer(t, 100, http.StatusBadGateway)
+	err := testClient().Send(context.Background(), srv.URL, testSendMessage())
+	var se *StatusError
+	if !errors.As(err, &se) || se.StatusCode != http.StatusBadGateway {
+		t.Fatalf("err = %v", err)
+	}
+	if n := atomic.LoadInt32(calls); n != 4 {
+		t.Errorf("attempts = %d, want 4", n)
+	}
+}
+
+func TestClientFailsFastOnClientErrors(t *testing.T) {
+	for _, status := range []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusConflict} {
+		srv, calls := flakyPeer(t, 100, status)
+		err := testClient().Send(context.Background(), srv.URL, testSendMessage())
+		var se *StatusError
+		if !errors.As(err, &se) || se.StatusCode != status {
+			t.Errorf("%d: err = %v", status, err)
+		}
+		if n := atomic.LoadInt32(calls); n != 1 {
+			t.Errorf("%d: attempts = %d, want 1", status, n)
+		}
+	}
+}
+
+func TestClientRetriesConnectionErrors(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	url := srv.URL
+	srv.Close()
+	if err := testClient().Send(context.Background(), url, testSendMessage()); err == nil {
+		t.Fatal("send to closed server succeeded")
+	}
+}
+
+func TestClientRetriesTimeouts(t *testing.T) {
+	var calls int32
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			<-release
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+	c := testClient()
+	c.Timeout = 20 * time.Millisecond
+	if err := c.Send(context.Background(), srv.URL, testSendMessage()); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(&calls); n != 2 {
+		t.Errorf("attempts = %d, want 2", n)
+	}
+}
+
+func TestClientRetriesSlowServer(t *testing.T) {
+	srv := newTestServer()
+	var calls int32
+	srv.payloads.SetDefault(func(ctx context.Context, msg ServiceMessage) error {
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(200 * time.Millisecond)
+		return nil
+	})
+	ts := httptest.NewServer(srv.Routes())
+	defer ts.Close()
+
+	// The first attempt times out while its handler is still running; the
+	// retries that follow must wait for it rather than fail or run again.
+	c := NewClient(nil)
+	c.Timeout = 50 * time.Millisecond
+	c.Backoff = Backoff{Initial: 20 * time.Millisecond, Max: 40 * time.Millisecond, Multiplier: 2, MaxAttempts: 20}
+	if err := c.Send(context.Background(), ts.URL, testSendMessage()); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Errorf("handler ran %d times, want 1", n)
+	}
+}
+
+func TestClientStopsOnCancel(t *testing.T) {
+	srv, calls := flakyPeer(t, 100, http.StatusServiceUnavailable)
+	c := testClient()
+	c.Backoff.Initial, c.Backoff.Max = time.Hour, time.Hour
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+	if err := c.Send(ctx, srv.URL, testSendMessage()); err == nil {
+		t.Fatal("Send succeeded")
+	}
+	if n := atomic.LoadInt32(calls); n != 1 {
+		t.Errorf("attempts = %d, want 1", n)
+	}
+}
+
+func TestBackoffDelay(t *testing.T) {
+	b := Backoff{Initial: 100 * time.Millisecond, Max: time.Second, Multiplier: 2, Jitter: 0.5}
+	mid := func() float64 { return 0.5 }
+	for n, want := range map[int]time.Duration{1: 100 * time.Millisecond, 2: 200 * time.Millisecond, 4: 800 * time.Millisecond, 5: time.Second, 50: time.Second} {
+		if got := b.delay(n, mid); got != want {
+			t.Errorf("delay(%d) = %s, want %s", n, got, want)
+		}
+	}
+	if lo, hi := b.delay(1, func() float64 { return 0 }), b.delay(1, func() float64 { return 1 }); lo != 50*time.Millisecond || hi != 150*time.Millisecond {
+		t.Errorf("jitter range = [%s, %s]", lo, hi)
+	}
+}
+
+func TestClientReusesIdempotencyKey(t *testing.T) {
+	var keys []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.Header.Get(idempotencyKeyHeader))
+		if len(keys) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+	c := testClient()
+	if err := c.Send(context.Background(), srv.URL, testSendMessage()); err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 3 || keys[0] == "" || keys[1] != keys[0] || keys[2] != keys[0] {
+		t.Errorf("idempotency keys = %q, want one key on every attempt", keys)
+	}
+	if err := c.Send(context.Background(), srv.URL, testSendMessage()); err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 4 || keys[3] == keys[0] {
+		t.Errorf("second Send reused key %q", keys[0])
+	}
+}
@@ -0,0 +1,248 @@
+// Command semverx parses, compares and bumps SemVerX versions from the
+// command line.
+//
+// Usage:
+//
+//	semverx parse <version>
+//	semverx compare <a> <b>
+//	semverx satisfies <version> <constraint>
+//	semverx bump <version> (--major | --minor | --patch | --promote <channel>)
+//
+// Every subcommand accepts --json for machine-readable output. The exit
+// status is 0 when the answer is true (compare: the versions are equal;
+// satisfies: the version matches), 1 when it is false, and 2 on usage or
+// parse errors.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/obinexus/rust-semverx/MVP/examples/drivers/semverx"
+)
+
+const (
+	exitTrue  = 0
+	exitFalse = 1
+	exitError = 2
+)
+
+const usage = `usage:
+  semverx parse <version>
+  semverx compare <a> <b>
+  semverx satisfies <version> <constraint>
+  semverx bump <version> (--major | --minor | --patch | --promote <channel>)
+flags:
+  --json  print JSON instead of text`
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+type command struct {
+	fs     *flag.FlagSet
+	json   bool
+	stdout io.Writer
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprintln(stderr, usage)
+		return exitError
+	}
+	name, args := args[0], args[1:]
+	c := &command{fs: flag.NewFlagSet(name, flag.ContinueOnError), stdout: stdout}
+	c.fs.SetOutput(io.Discard)
+	c.fs.BoolVar(&c.json, "json", false, "print JSON")
+
+	var (
+		code int
+		err  error
+	)
+	switch name {
+	case "parse":
+		code, err = c.parse(args)
+	case "compare":
+		code, err = c.compare(args)
+	case "satisfies":
+		code, err = c.satisfies(args)
+	case "bump":
+		code, err = c.bump(args)
+	default:
+		err = fmt.Errorf("unknown command %q\n%s", name, usage)
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, "semverx:", err)
+		return exitError
+	}
+	return code
+}
+
+// positional parses flags wherever they appear among args and returns
+// exactly n positional arguments.
+func (c *command) positional(args []string, n int) ([]string, error) {
+	var pos []string
+	for {
+		if err := c.fs.Parse(args); err != nil {
+			return nil, err
+		}
+		if c.fs.NArg() == 0 {
+			break
+		}
+		pos = append(pos, c.fs.Arg(0))
+		args = c.fs.Args()[1:]
+	}
+	if len(pos) != n {
+		return nil, fmt.Errorf("%s: want %d argument(s), got %d\n%s", c.fs.Name(), n, len(pos), usage)
+	}
+	return pos, nil
+}
+
+func (c *command) print(text string, v interface{}) {
+	if c.json {
+		enc := json.NewEncoder(c.stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(v)
+		return
+	}
+	fmt.Fprintln(c.stdout, text)
+}
+
+type versionJSON struct {
+	Major        int    `json:"major"`
+	Minor        int    `json:"minor"`
+	Patch        int    `json:"patch"`
+	MajorChannel string `json:"major_channel"`
+	MinorChannel string `json:"minor_channel"`
+	PatchChannel string `json:"patch_channel"`
+	Raw          string `json:"raw"`
+}
+
+func toJSON(v semverx.Version) versionJSON {
+	return versionJSON{
+		Major: v.Major, Minor: v.Minor, Patch: v.Patch,
+		MajorChannel: v.MajorChannel.String(),
+		MinorChannel: v.MinorChannel.String(),
+		PatchChannel: v.PatchChannel.String(),
+		Raw:          v.String(),
+	}
+}
+
+func (c *command) parse(args []string) (int, error) {
+	pos, err := c.positional(args, 1)
+	if err != nil {
+		return 0, err
+	}
+	v, err := semverx.ParseVersion(pos[0])
+	if err != nil {
+		return 0, err
+	}
+	c.print(fmt.Sprintf("%s\nmajor %d (%s)\nminor %d (%s)\npatch %d (%s)",
+		v, v.Major, v.MajorChannel, v.Minor, v.MinorChannel, v.Patch, v.PatchChannel), toJSON(v))
+	return exitTrue, nil
+}
+
+func (c *command) compare(args []string) (int, error) {
+	pos, err := c.positional(args, 2)
+	if err != nil {
+		return 0, err
+	}
+	a, err := semverx.ParseVersion(pos[0])
+	if err != nil {
+		return 0, err
+	}
+	b, err := semverx.ParseVersion(pos[1])
+	if err != nil {
+		return 0, err
+	}
+	cmp := a.Compare(b)
+	rel := map[int]string{-1: "<", 0: "=", 1: ">"}[cmp]
+	c.print(fmt.Sprintf("%s %s %s", a, rel, b), struct {
+		A      string `json:"a"`
+		B      string `json:"b"`
+		Result int    `json:"result"`
+	}{a.String(), b.String(), cmp})
+	if cmp != 0 {
+		return exitFalse, nil
+	}
+	return exitTrue, nil
+}
+
+func (c *command) satisfies(args []string) (int, error) {
+	pos, err := c.positional(args, 2)
+	if err != nil {
+		return 0, err
+	}
+	v, err := semverx.ParseVersion(pos[0])
+	if err != nil {
+		return 0, err
+	}
+	con, err := semverx.ParseConstraint(pos[1])
+	if err != nil {
+		return 0, err
+	}
+	ok := con.Matches(v)
+	text := fmt.Sprintf("%s satisfies %s", v, con)
+	if !ok {
+		text = fmt.Sprintf("%s does not satisfy %s", v, con)
+	}
+	c.print(text, struct {
+		Version    string `json:"version"`
+		Constraint string `json:"constraint"`
+		Satisfies  bool   `json:"satisfies"`
+	}{v.String(), con.String(), ok})
+	if !ok {
+		return exitFalse, nil
+	}
+	return exitTrue, nil
+}
+
+func (c *command) bump(args []string) (int, error) {
+	major := c.fs.Bool("major", false, "increment the major number")
+	minor := c.fs.Bool("minor", false, "increment the minor number")
+	patch := c.fs.Bool("patch", false, "increment the patch number")
+	promote := c.fs.String("promote", "", "promote to a more stable channel")
+	pos, err := c.positional(args, 1)
+	if err != nil {
+		return 0, err
+	}
+	v, err := semverx.ParseVersion(pos[0])
+	if err != nil {
+		return 0, err
+	}
+
+	n := 0
+	for _, set := range []bool{*major, *minor, *patch, *promote != ""} {
+		if set {
+			n++
+		}
+	}
+	if n != 1 {
+		return 0, fmt.Errorf("bump: want exactly one of --major, --minor, --patch, --promote\n%s", usage)
+	}
+	var next semverx.Version
+	switch {
+	case *major:
+		next, err = v.IncMajor()
+	case *minor:
+		next, err = v.IncMinor()
+	case *patch:
+		next, err = v.IncPatch()
+	default:
+		var ch semverx.Channel
+		if ch, err = semverx.ParseChannel(*promote); err == nil {
+			next, err = v.Promote(ch)
+		}
+	}
+	if err != nil {
+		return 0, err
+	}
+	c.print(next.String(), struct {
+		From string `json:"from"`
+		To   string `json:"to"`
+	}{v.String(), next.String()})
+	return exitTrue, nil
+}
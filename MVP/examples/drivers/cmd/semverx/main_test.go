@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func runCLI(args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := run(args, &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		args []string
+		code int
+		out  string
+	}{
+		{[]string{"parse", "v1.stable.2.beta.3.rc"}, 0, "minor 2 (beta)"},
+		{[]string{"compare", "v1.stable.0.stable.0.stable", "v1.stable.0.stable.0.stable"}, 0, " = "},
+		{[]string{"compare", "v1.stable.2.beta.0.stable", "v1.stable.2.stable.0.stable"}, 1, " < "},
+		{[]string{"satisfies", "v1.stable.5.stable.0.stable", "^v1.stable.2.stable.0.stable"}, 0, "satisfies"},
+		{[]string{"satisfies", "v1.stable.5.beta.0.stable", "^v1.stable.2.stable.0.stable"}, 1, "does not satisfy"},
+		{[]string{"bump", "v1.stable.2.stable.3.stable", "--minor"}, 0, "v1.stable.3.stable.0.stable"},
+		{[]string{"bump", "--major", "v1.stable.2.stable.3.stable"}, 0, "v2.stable.0.stable.0.stable"},
+		{[]string{"bump", "v1.stable.2.beta.3.stable", "--promote", "rc"}, 0, "v1.stable.2.rc.0.stable"},
+	}
+	for _, tt := range tests {
+		code, out, errOut := runCLI(tt.args...)
+		if code != tt.code {
+			t.Errorf("%q: exit %d, want %d (stderr %q)", tt.args, code, tt.code, errOut)
+		}
+		if !strings.Contains(out, tt.out) {
+			t.Errorf("%q: output %q does not contain %q", tt.args, out, tt.out)
+		}
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	for _, args := range [][]string{
+		nil,
+		{"frobnicate"},
+		{"parse"},
+		{"parse", "1.0.0"},
+		{"compare", "v1.stable.0.stable.0.stable"},
+		{"compare", "v1.stable.0.stable.0.stable", "bogus"},
+		{"satisfies", "v1.stable.0.stable.0.stable", ">>v1"},
+		{"bump", "v1.stable.0.stable.0.stable"},
+		{"bump", "v1.stable.0.stable.0.stable", "--minor", "--patch"},
+		{"bump", "v1.stable.0.stable.0.stable", "--promote", "rc"},
+		{"bump", "v1.stable.0.stable." + strconv.Itoa(math.MaxInt) + ".stable", "--patch"},
+		{"bump", "v" + strconv.Itoa(math.MaxInt) + ".stable.0.stable.0.stable", "--major"},
+		{"parse", "--nope", "v1.stable.0.stable.0.stable"},
+	} {
+		if code, _, errOut := runCLI(args...); code != 2 || errOut == "" {
+			t.Errorf("%q: exit %d, stderr %q; want 2 with a message", args, code, errOut)
+		}
+	}
+}
+
+func TestRunJSON(t *testing.T) {
+	code, out, _ := runCLI("satisfies", "--json", "v1.stable.0.stable.0.stable", ">=v1.stable.0.stable.0.stable")
+	if code != 0 {
+		t.Fatalf("exit %d", code)
+	}
+	var got struct {
+		Version    string `json:"version"`
+		Constraint string `json:"constraint"`
+		Satisfies  bool   `json:"satisfies"`
+	}
+	if err := json.Unmarshal([]byte(out), &got); err != nil {
+		t.Fatalf("output %q: %v", out, err)
+	}
+	if !got.Satisfies || got.Version != "v1.stable.0.stable.0.stable" {
+		t.Errorf("got %+v", got)
+	}
+
+	_, out, _ = runCLI("parse", "v2.stable.1.beta.0.rc", "--json")
+	var v versionJSON
+	if err := json.Unmarshal([]byte(out), &v); err != nil {
+		t.Fatalf("output %q: %v", out, err)
+	}
+	if v.Major != 2 || v.MinorChannel != "beta" || v.Raw != "v2.stable.1.beta.0.rc" {
+		t.Errorf("got %+v", v)
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/obinexus/rust-semverx/MVP/examples/drivers/semverx"
+)
+
+// CompatResponse is the /compat response body. Matrix[i][j] reports
+// whether a node on Versions[i] accepts a peer on Versions[j]; Clique lists
+// the indices of the largest mutually compatible subset.
+type CompatResponse struct {
+	Versions []string `json:"versions"`
+	Matrix   [][]bool `json:"matrix"`
+	Clique   []int    `json:"clique"`
+}
+
+// maxCompatVersions caps the versions one /compat request may compare; the
+// largest compatible set is exponential in their number.
+const maxCompatVersions = 32
+
+// compatHandler evaluates the pairwise compatibility of the comma-separated
+// versions query parameter. Like /admin/acl it requires the admin token.
+func (s *Server) compatHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.authorizeAdmin(w, r) {
+		return
+	}
+	list := r.URL.Query().Get("versions")
+	if list == "" {
+		writeError(w, http.StatusBadRequest, "versions query parameter is required")
+		return
+	}
+	items := strings.Split(list, ",")
+	if len(items) > maxCompatVersions {
+		writeError(w, http.StatusBadRequest,
+			fmt.Sprintf("%d versions exceed the limit of %d", len(items), maxCompatVersions))
+		return
+	}
+	var versions []semverx.Version
+	var names []string
+	for _, item := range items {
+		v, err := semverx.ParseVersion(strings.TrimSpace(item))
+		if err != nil {
+			merr := rejectInvalid(http.StatusBadRequest, err.Error(), err)
+			writeJSON(w, merr.status, merr.fields)
+			return
+		}
+		versions = append(versions, v)
+		names = append(names, v.String())
+	}
+	writeJSON(w, http.StatusOK, CompatResponse{
+		Versions: names,
+		Matrix:   semverx.CompatibilityMatrix(versions),
+		Clique:   semverx.LargestCompatibleSet(versions),
+	})
+}
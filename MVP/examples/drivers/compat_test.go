@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func newCompatServer() http.Handler {
+	cfg := DefaultConfig()
+	cfg.AdminToken = []byte("s3cret")
+	return NewServer(cfg, WithLogOutput(io.Discard)).Routes()
+}
+
+func getCompat(h http.Handler, query, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/compat"+query, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestCompatHandler(t *testing.T) {
+	h := newCompatServer()
+	rec := getCompat(h, "?versions=v1.stable.0.stable.0.stable,v2.stable.0.stable.0.stable,v1.stable.1.stable.0.stable", "s3cret")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", rec.Code, rec.Body)
+	}
+	var got CompatResponse
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	want := CompatResponse{
+		Versions: []string{"v1.stable.0.stable.0.stable", "v2.stable.0.stable.0.stable", "v1.stable.1.stable.0.stable"},
+		Matrix:   [][]bool{{true, false, true}, {false, true, false}, {true, false, true}},
+		Clique:   []int{0, 2},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	tooMany := "?versions=" + strings.Repeat("v1.stable.0.stable.0.stable,", maxCompatVersions) + "v1.stable.0.stable.0.stable"
+	for _, q := range []string{"", "?versions=", "?versions=v1.stable.0.stable.0.stable,1.0.0", tooMany} {
+		if rec := getCompat(h, q, "s3cret"); rec.Code != http.StatusBadRequest {
+			t.Errorf("%.40q: status = %d, want 400", q, rec.Code)
+		}
+	}
+}
+
+func TestCompatRequiresAdmin(t *testing.T) {
+	q := "?versions=v1.stable.0.stable.0.stable"
+	h := newCompatServer()
+	for _, token := range []string{"", "wrong"} {
+		if rec := getCompat(h, q, token); rec.Code != http.StatusUnauthorized {
+			t.Errorf("token %q: status = %d, want 401", token, rec.Code)
+		}
+	}
+	if rec := getCompat(newTestServer().Routes(), q, "s3cret"); rec.Code != http.StatusNotFound {
+		t.Errorf("no admin token configured: status = %d, want 404", rec.Code)
+	}
+}
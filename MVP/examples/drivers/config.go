@@ -0,0 +1,502 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/obinexus/rust-semverx/MVP/examples/drivers/semverx"
+)
+
+const (
+	defaultAddr      = ":3002"
+	defaultServiceID = "go-service"
+	defaultVersion   = "v1.stable.0.stable.0.stable"
+
+	defaultShutdownGrace = 10 * time.Second
+	defaultPeerTTL       = 5 * time.Minute
+	defaultMaxBatchSize  = 100
+	defaultMaxBodyBytes  = 1 << 20
+	defaultMaxHops       = 4
+	defaultFwdTimeout    = 5 * time.Second
+
+	defaultReadHeaderTimeout = 5 * time.Second
+	defaultReadTimeout       = 30 * time.Second
+	defaultWriteTimeout      = 30 * time.Second
+	defaultIdleTimeout       = 2 * time.Minute
+
+	defaultRateLimit     = 50
+	defaultRateBurst     = 100
+	defaultAnonRateLimit = 5
+	defaultAnonRateBurst = 10
+
+	defaultBusBuffer  = 64
+	defaultStreamPing = 30 * time.Second
+
+	defaultPeerSnapshotInterval = 30 * time.Second
+	defaultIdempotencyTTL       = 10 * time.Minute
+)
+
+// Config is the resolved runtime configuration of the service.
+type Config struct {
+	Addr      string
+	ServiceID string
+	Version   semverx.Version
+	// Supported lists every wire version this node can speak during
+	// negotiation. When empty only Version is offered.
+	Supported []semverx.Version
+
+	// ShutdownGrace bounds how long in-flight requests may run after a
+	// shutdown signal before connections are closed.
+	ShutdownGrace time.Duration
+	// ReadHeaderTimeout, ReadTimeout, WriteTimeout and IdleTimeout are
+	// passed to http.Server. They bound how long a client may take to send
+	// its headers and its whole request, how long a response may take, and
+	// how long a keep-alive connection may sit idle. Zero disables each
+	// limit. /stream connections are exempt once upgraded.
+	ReadHeaderTimeout time.Duration
+	ReadTimeout       time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	// PeerTTL is how long a peer stays registered after its last message.
+	// Zero keeps peers forever.
+	PeerTTL time.Duration
+	// PeerStore, when set, is a file the peer registry is snapshotted to
+	// every PeerSnapshotInterval and on shutdown, and rehydrated from at
+	// startup.
+	PeerStore            string
+	PeerSnapshotInterval time.Duration
+	// Secret, when set, requires every message to carry a valid HMAC
+	// signature.
+	Secret []byte
+	// ReplayWindow is the allowed clock skew for message timestamps.
+	// Messages outside it, or repeated within it, are rejected. Zero
+	// disables replay protection.
+	ReplayWindow time.Duration
+	// IdempotencyTTL is how long the response to a /message request with
+	// an Idempotency-Key header is replayed to retries. Zero disables
+	// idempotency keys.
+	IdempotencyTTL time.Duration
+	// MaxBatchSize caps the number of messages in one /messages/batch
+	// request.
+	MaxBatchSize int
+	// MaxBodyBytes caps the request body of /message, /messages/batch
+	// and /negotiate. Zero disables the limit.
+	MaxBodyBytes int64
+	// PeerURLs maps peer service IDs to the base URL messages are
+	// forwarded to.
+	PeerURLs map[string]string
+	// MaxHops is how many services a forwarded message may pass through.
+	MaxHops int
+	// ForwardTimeout bounds each forwarding request.
+	ForwardTimeout time.Duration
+	// Stability rejects peers and negotiated versions less stable than
+	// its floor.
+	Stability semverx.StabilityPolicy
+	// ReadyPeerGrace, when positive, makes the readiness probe fail if no
+	// peer has been seen this long after startup.
+	ReadyPeerGrace time.Duration
+	// Upstreams are URLs that must answer GET with 2xx for the node to be
+	// ready.
+	Upstreams []string
+	// LogLevel is the minimum level of emitted log entries.
+	LogLevel slog.Level
+	// RateLimit bounds the requests each sender may make to /message,
+	// /messages/batch and /negotiate. AnonRateLimit applies, as one shared
+	// bucket, to requests that do not identify their sender.
+	RateLimit     RateLimit
+	AnonRateLimit RateLimit
+	// BusBuffer is the per-subscriber buffer of the message bus, and
+	// BusOverflow what happens when a subscriber's buffer is full.
+	BusBuffer   int
+	BusOverflow OverflowPolicy
+	// StreamPing is how often /stream connections are pinged. A peer that
+	// answers nothing for two intervals is disconnected. Zero disables
+	// keepalive.
+	StreamPing time.Duration
+	// ACL decides which peers may send messages and negotiate. It can be
+	// replaced at runtime through /admin/acl.
+	ACL ACL
+	// AdminToken, when set, enables the /admin endpoints for requests
+	// presenting it as a bearer token.
+	AdminToken []byte
+	// CORS controls which browser origins may call the service.
+	CORS CORS
+	// RequiredHeaders lists, in canonical form, the headers every /message
+	// request must carry; see withRequiredHeaders. Empty requires none.
+	RequiredHeaders []string
+}
+
+// DefaultConfig returns the configuration used when no flags or
+// environment variables are set.
+func DefaultConfig() Config {
+	return Config{
+		Addr:      defaultAddr,
+		ServiceID: defaultServiceID,
+		Version:   semverx.MustParseVersion(defaultVersion),
+
+		ShutdownGrace: defaultShutdownGrace,
+		PeerTTL:       defaultPeerTTL,
+		MaxBatchSize:  defaultMaxBatchSize,
+		MaxBodyBytes:  defaultMaxBodyBytes,
+
+		ReadHeaderTimeout: defaultReadHeaderTimeout,
+		ReadTimeout:       defaultReadTimeout,
+		WriteTimeout:      defaultWriteTimeout,
+		IdleTimeout:       defaultIdleTimeout,
+
+		PeerSnapshotInterval: defaultPeerSnapshotInterval,
+		IdempotencyTTL:       defaultIdempotencyTTL,
+
+		MaxHops:        defaultMaxHops,
+		ForwardTimeout: defaultFwdTimeout,
+
+		RateLimit:     RateLimit{Rate: defaultRateLimit, Burst: defaultRateBurst},
+		AnonRateLimit: RateLimit{Rate: defaultAnonRateLimit, Burst: defaultAnonRateBurst},
+
+		BusBuffer:   defaultBusBuffer,
+		BusOverflow: OverflowDrop,
+		StreamPing:  defaultStreamPing,
+
+		CORS: CORS{
+			AllowedMethods: splitList(defaultCORSMethods),
+			AllowedHeaders: splitList(defaultCORSHeaders),
+		},
+		RequiredHeaders: headerList(defaultRequiredHeaders),
+	}
+}
+
+// loadConfig resolves the configuration from command-line flags, falling
+// back to SEMVERX_* environment variables and then to the defaults. Usage
+// and flag errors are printed to output.
+func loadConfig(args []string, getenv func(string) string, output io.Writer) (Config, error) {
+	env := func(key, def string) string {
+		if v := getenv(key); v != "" {
+			return v
+		}
+		return def
+	}
+	envInt := func(key string, def int) (int, error) {
+		v := getenv(key)
+		if v == "" {
+			return def, nil
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", key, err)
+		}
+		return n, nil
+	}
+	envFloat := func(key string, def float64) (float64, error) {
+		v := getenv(key)
+		if v == "" {
+			return def, nil
+		}
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", key, err)
+		}
+		return f, nil
+	}
+	envBool := func(key string, def bool) (bool, error) {
+		v := getenv(key)
+		if v == "" {
+			return def, nil
+		}
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return false, fmt.Errorf("%s: %w", key, err)
+		}
+		return b, nil
+	}
+	envDuration := func(key string, def time.Duration) (time.Duration, error) {
+		v := getenv(key)
+		if v == "" {
+			return def, nil
+		}
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", key, err)
+		}
+		return d, nil
+	}
+
+	grace, err := envDuration("SEMVERX_SHUTDOWN_GRACE", defaultShutdownGrace)
+	if err != nil {
+		return Config{}, err
+	}
+	readHeaderTimeout, err := envDuration("SEMVERX_READ_HEADER_TIMEOUT", defaultReadHeaderTimeout)
+	if err != nil {
+		return Config{}, err
+	}
+	readTimeout, err := envDuration("SEMVERX_READ_TIMEOUT", defaultReadTimeout)
+	if err != nil {
+		return Config{}, err
+	}
+	writeTimeout, err := envDuration("SEMVERX_WRITE_TIMEOUT", defaultWriteTimeout)
+	if err != nil {
+		return Config{}, err
+	}
+	idleTimeout, err := envDuration("SEMVERX_IDLE_TIMEOUT", defaultIdleTimeout)
+	if err != nil {
+		return Config{}, err
+	}
+	peerTTL, err := envDuration("SEMVERX_PEER_TTL", defaultPeerTTL)
+	if err != nil {
+		return Config{}, err
+	}
+	peerSnapshot, err := envDuration("SEMVERX_PEER_SNAPSHOT_INTERVAL", defaultPeerSnapshotInterval)
+	if err != nil {
+		return Config{}, err
+	}
+	replayWindow, err := envDuration("SEMVERX_REPLAY_WINDOW", 0)
+	if err != nil {
+		return Config{}, err
+	}
+	idempotencyTTL, err := envDuration("SEMVERX_IDEMPOTENCY_TTL", defaultIdempotencyTTL)
+	if err != nil {
+		return Config{}, err
+	}
+	maxHops, err := envInt("SEMVERX_MAX_HOPS", defaultMaxHops)
+	if err != nil {
+		return Config{}, err
+	}
+	fwdTimeout, err := envDuration("SEMVERX_FORWARD_TIMEOUT", defaultFwdTimeout)
+	if err != nil {
+		return Config{}, err
+	}
+	readyPeerGrace, err := envDuration("SEMVERX_READY_PEER_GRACE", 0)
+	if err != nil {
+		return Config{}, err
+	}
+	var logLevel slog.Level
+	if v := getenv("SEMVERX_LOG_LEVEL"); v != "" {
+		if err := logLevel.UnmarshalText([]byte(v)); err != nil {
+			return Config{}, fmt.Errorf("SEMVERX_LOG_LEVEL: %w", err)
+		}
+	}
+	maxBatch, err := envInt("SEMVERX_MAX_BATCH", defaultMaxBatchSize)
+	if err != nil {
+		return Config{}, err
+	}
+	maxBody, err := envInt("SEMVERX_MAX_BODY", defaultMaxBodyBytes)
+	if err != nil {
+		return Config{}, err
+	}
+	busBuffer, err := envInt("SEMVERX_BUS_BUFFER", defaultBusBuffer)
+	if err != nil {
+		return Config{}, err
+	}
+	streamPing, err := envDuration("SEMVERX_STREAM_PING", defaultStreamPing)
+	if err != nil {
+		return Config{}, err
+	}
+	corsCredentials, err := envBool("SEMVERX_CORS_CREDENTIALS", false)
+	if err != nil {
+		return Config{}, err
+	}
+	var rate, anonRate RateLimit
+	if rate.Rate, err = envFloat("SEMVERX_RATE_LIMIT", defaultRateLimit); err != nil {
+		return Config{}, err
+	}
+	if rate.Burst, err = envInt("SEMVERX_RATE_BURST", defaultRateBurst); err != nil {
+		return Config{}, err
+	}
+	if anonRate.Rate, err = envFloat("SEMVERX_ANON_RATE_LIMIT", defaultAnonRateLimit); err != nil {
+		return Config{}, err
+	}
+	if anonRate.Burst, err = envInt("SEMVERX_ANON_RATE_BURST", defaultAnonRateBurst); err != nil {
+		return Config{}, err
+	}
+
+	fs := flag.NewFlagSet("go-service", flag.ContinueOnError)
+	fs.SetOutput(output)
+	addr := fs.String("addr", env("SEMVERX_ADDR", defaultAddr), "listen address (env SEMVERX_ADDR)")
+	serviceID := fs.String("service-id", env("SEMVERX_SERVICE_ID", defaultServiceID), "service ID reported to peers (env SEMVERX_SERVICE_ID)")
+	version := fs.String("version", env("SEMVERX_VERSION", defaultVersion), "SemVerX version of this node (env SEMVERX_VERSION)")
+	secret := fs.String("secret", env("SEMVERX_SECRET", ""), "shared HMAC secret for message signatures (env SEMVERX_SECRET)")
+	supported := fs.String("supported", env("SEMVERX_SUPPORTED", ""), "comma-separated versions offered in negotiation (env SEMVERX_SUPPORTED)")
+	fs.DurationVar(&grace, "shutdown-grace", grace, "time allowed for in-flight requests on shutdown (env SEMVERX_SHUTDOWN_GRACE)")
+	fs.DurationVar(&readHeaderTimeout, "read-header-timeout", readHeaderTimeout, "time allowed to read request headers, 0 disables (env SEMVERX_READ_HEADER_TIMEOUT)")
+	fs.DurationVar(&readTimeout, "read-timeout", readTimeout, "time allowed to read a whole request, 0 disables (env SEMVERX_READ_TIMEOUT)")
+	fs.DurationVar(&writeTimeout, "write-timeout", writeTimeout, "time allowed to write a response, 0 disables (env SEMVERX_WRITE_TIMEOUT)")
+	fs.DurationVar(&idleTimeout, "idle-timeout", idleTimeout, "how long idle keep-alive connections stay open, 0 disables (env SEMVERX_IDLE_TIMEOUT)")
+	fs.DurationVar(&peerTTL, "peer-ttl", peerTTL, "evict peers not seen for this long, 0 to keep forever (env SEMVERX_PEER_TTL)")
+	peerStore := fs.String("peer-store", env("SEMVERX_PEER_STORE", ""), "file to persist known peers in across restarts, empty keeps them in memory (env SEMVERX_PEER_STORE)")
+	fs.DurationVar(&peerSnapshot, "peer-snapshot-interval", peerSnapshot, "how often -peer-store is written (env SEMVERX_PEER_SNAPSHOT_INTERVAL)")
+	fs.DurationVar(&replayWindow, "replay-window", replayWindow, "allowed message clock skew, 0 disables replay protection (env SEMVERX_REPLAY_WINDOW)")
+	fs.DurationVar(&idempotencyTTL, "idempotency-ttl", idempotencyTTL, "how long /message responses are replayed for a repeated Idempotency-Key, 0 disables (env SEMVERX_IDEMPOTENCY_TTL)")
+	fs.IntVar(&maxBatch, "max-batch", maxBatch, "maximum messages per batch request (env SEMVERX_MAX_BATCH)")
+	fs.IntVar(&maxBody, "max-body", maxBody, "maximum request body size in bytes, 0 disables (env SEMVERX_MAX_BODY)")
+	peers := fs.String("peers", env("SEMVERX_PEERS", ""), "comma-separated id=url peers to forward to (env SEMVERX_PEERS)")
+	fs.IntVar(&maxHops, "max-hops", maxHops, "maximum services a forwarded message may visit (env SEMVERX_MAX_HOPS)")
+	fs.DurationVar(&fwdTimeout, "forward-timeout", fwdTimeout, "timeout per forwarded request (env SEMVERX_FORWARD_TIMEOUT)")
+	minStability := fs.String("min-stability", env("SEMVERX_MIN_STABILITY", ""), "reject versions with any component below this channel, e.g. rc (env SEMVERX_MIN_STABILITY)")
+	fs.DurationVar(&readyPeerGrace, "ready-peer-grace", readyPeerGrace, "report not ready if no peer is seen this long after startup, 0 disables (env SEMVERX_READY_PEER_GRACE)")
+	upstreams := fs.String("upstreams", env("SEMVERX_UPSTREAMS", ""), "comma-separated URLs that must be reachable for readiness (env SEMVERX_UPSTREAMS)")
+	fs.TextVar(&logLevel, "log-level", logLevel, "minimum log level: debug, info, warn or error (env SEMVERX_LOG_LEVEL)")
+	fs.Float64Var(&rate.Rate, "rate-limit", rate.Rate, "requests per second allowed per sender, 0 disables (env SEMVERX_RATE_LIMIT)")
+	fs.IntVar(&rate.Burst, "rate-burst", rate.Burst, "burst size per sender (env SEMVERX_RATE_BURST)")
+	fs.Float64Var(&anonRate.Rate, "anon-rate-limit", anonRate.Rate, "requests per second shared by unidentified senders, 0 disables (env SEMVERX_ANON_RATE_LIMIT)")
+	fs.IntVar(&anonRate.Burst, "anon-rate-burst", anonRate.Burst, "burst size for unidentified senders (env SEMVERX_ANON_RATE_BURST)")
+	fs.IntVar(&busBuffer, "bus-buffer", busBuffer, "messages buffered per bus subscriber (env SEMVERX_BUS_BUFFER)")
+	busOverflow := fs.String("bus-overflow", env("SEMVERX_BUS_OVERFLOW", OverflowDrop.String()), "drop or block when a bus subscriber falls behind (env SEMVERX_BUS_OVERFLOW)")
+	fs.DurationVar(&streamPing, "stream-ping", streamPing, "keepalive ping interval of /stream connections, 0 disables (env SEMVERX_STREAM_PING)")
+	allowPeers := fs.String("allow-peers", env("SEMVERX_ALLOW_PEERS", ""), "comma-separated service IDs that alone may send messages (env SEMVERX_ALLOW_PEERS)")
+	denyPeers := fs.String("deny-peers", env("SEMVERX_DENY_PEERS", ""), "comma-separated service IDs that may not send messages; wins over -allow-peers (env SEMVERX_DENY_PEERS)")
+	adminToken := fs.String("admin-token", env("SEMVERX_ADMIN_TOKEN", ""), "bearer token for /admin endpoints, empty disables them (env SEMVERX_ADMIN_TOKEN)")
+	corsOrigins := fs.String("cors-origins", env("SEMVERX_CORS_ORIGINS", ""), "comma-separated browser origins allowed to call the service, * for any (env SEMVERX_CORS_ORIGINS)")
+	corsMethods := fs.String("cors-methods", env("SEMVERX_CORS_METHODS", defaultCORSMethods), "comma-separated methods allowed cross-origin (env SEMVERX_CORS_METHODS)")
+	corsHeaders := fs.String("cors-headers", env("SEMVERX_CORS_HEADERS", defaultCORSHeaders), "comma-separated request headers allowed cross-origin (env SEMVERX_CORS_HEADERS)")
+	requiredHeaders := fs.String("require-headers", env("SEMVERX_REQUIRE_HEADERS", defaultRequiredHeaders), "comma-separated headers /message requests must carry, empty for none (env SEMVERX_REQUIRE_HEADERS)")
+	fs.BoolVar(&corsCredentials, "cors-credentials", corsCredentials, "allow credentialed cross-origin requests (env SEMVERX_CORS_CREDENTIALS)")
+	if err := fs.Parse(args); err != nil {
+		return Config{}, err
+	}
+
+	v, err := semverx.ParseVersion(*version)
+	if err != nil {
+		return Config{}, fmt.Errorf("-version: %w", err)
+	}
+	var sv []semverx.Version
+	if *supported != "" {
+		for _, raw := range strings.Split(*supported, ",") {
+			v, err := semverx.ParseVersion(strings.TrimSpace(raw))
+			if err != nil {
+				return Config{}, fmt.Errorf("-supported: %w", err)
+			}
+			sv = append(sv, v)
+		}
+	}
+	var peerURLs map[string]string
+	if *peers != "" {
+		peerURLs = make(map[string]string)
+		for _, kv := range strings.Split(*peers, ",") {
+			id, url, ok := strings.Cut(strings.TrimSpace(kv), "=")
+			if !ok || id == "" || url == "" {
+				return Config{}, fmt.Errorf("-peers: %q is not id=url", kv)
+			}
+			peerURLs[id] = strings.TrimSuffix(url, "/")
+		}
+	}
+	var stability semverx.StabilityPolicy
+	if *minStability != "" {
+		if stability.MinChannel, err = semverx.ParseChannel(*minStability); err != nil {
+			return Config{}, fmt.Errorf("-min-stability: %w", err)
+		}
+	}
+	overflow, err := ParseOverflowPolicy(*busOverflow)
+	if err != nil {
+		return Config{}, fmt.Errorf("-bus-overflow: %w", err)
+	}
+	cors := CORS{
+		AllowedOrigins:   splitList(*corsOrigins),
+		AllowedMethods:   splitList(*corsMethods),
+		AllowedHeaders:   splitList(*corsHeaders),
+		AllowCredentials: corsCredentials,
+	}
+	if cors.AllowCredentials && slices.Contains(cors.AllowedOrigins, "*") {
+		return Config{}, fmt.Errorf("-cors-credentials cannot be combined with the * origin")
+	}
+	if *serviceID == "" {
+		return Config{}, fmt.Errorf("-service-id must not be empty")
+	}
+	if grace < 0 {
+		return Config{}, fmt.Errorf("-shutdown-grace must not be negative")
+	}
+	if readHeaderTimeout < 0 || readTimeout < 0 || writeTimeout < 0 || idleTimeout < 0 {
+		return Config{}, fmt.Errorf("-read-header-timeout, -read-timeout, -write-timeout and -idle-timeout must not be negative")
+	}
+	if peerSnapshot <= 0 {
+		return Config{}, fmt.Errorf("-peer-snapshot-interval must be positive")
+	}
+	if replayWindow < 0 {
+		return Config{}, fmt.Errorf("-replay-window must not be negative")
+	}
+	if idempotencyTTL < 0 {
+		return Config{}, fmt.Errorf("-idempotency-ttl must not be negative")
+	}
+	if maxBatch < 1 {
+		return Config{}, fmt.Errorf("-max-batch must be at least 1")
+	}
+	if maxHops < 1 {
+		return Config{}, fmt.Errorf("-max-hops must be at least 1")
+	}
+	if busBuffer < 0 {
+		return Config{}, fmt.Errorf("-bus-buffer must not be negative")
+	}
+	if streamPing < 0 {
+		return Config{}, fmt.Errorf("-stream-ping must not be negative")
+	}
+	if maxBody < 0 {
+		return Config{}, fmt.Errorf("-max-body must not be negative")
+	}
+	for _, l := range []struct {
+		flag string
+		RateLimit
+	}{{"-rate-limit", rate}, {"-anon-rate-limit", anonRate}} {
+		if l.Rate < 0 || l.Burst < 0 || (l.Rate > 0 && l.Burst == 0) {
+			return Config{}, fmt.Errorf("%s needs a non-negative rate and, when enabled, a positive burst", l.flag)
+		}
+	}
+	cfg := Config{
+		Addr:          *addr,
+		ServiceID:     *serviceID,
+		Version:       v,
+		Supported:     sv,
+		ShutdownGrace: grace,
+		PeerTTL:       peerTTL,
+		ReplayWindow:  replayWindow,
+		MaxBatchSize:  maxBatch,
+		MaxBodyBytes:  int64(maxBody),
+		LogLevel:      logLevel,
+		Stability:     stability,
+
+		ReadHeaderTimeout: readHeaderTimeout,
+		ReadTimeout:       readTimeout,
+		WriteTimeout:      writeTimeout,
+		IdleTimeout:       idleTimeout,
+
+		PeerStore:            *peerStore,
+		PeerSnapshotInterval: peerSnapshot,
+		IdempotencyTTL:       idempotencyTTL,
+
+		ReadyPeerGrace: readyPeerGrace,
+		Upstreams:      splitList(*upstreams),
+
+		PeerURLs:       peerURLs,
+		MaxHops:        maxHops,
+		ForwardTimeout: fwdTimeout,
+
+		RateLimit:     rate,
+		AnonRateLimit: anonRate,
+
+		BusBuffer:   busBuffer,
+		BusOverflow: overflow,
+		StreamPing:  streamPing,
+
+		ACL:  ACL{Allow: splitList(*allowPeers), Deny: splitList(*denyPeers)},
+		CORS: cors,
+	}
+	cfg.RequiredHeaders = headerList(*requiredHeaders)
+	if *secret != "" {
+		cfg.Secret = []byte(*secret)
+	}
+	if *adminToken != "" {
+		cfg.AdminToken = []byte(*adminToken)
+	}
+	return cfg, nil
+}
+
+// supportedVersions returns the versions offered during negotiation.
+func (c Config) supportedVersions() []semverx.Version {
+	if len(c.Supported) == 0 {
+		return []semverx.Version{c.Version}
+	}
+	return c.Supported
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"io"
+	"log/slog"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/obinexus/rust-semverx/MVP/examples/drivers/semverx"
+)
+
+func TestLoadConfig(t *testing.T) {
+	env := map[string]string{
+		"SEMVERX_ADDR":           ":4000",
+		"SEMVERX_SERVICE_ID":     "env-service",
+		"SEMVERX_SHUTDOWN_GRACE": "3s",
+		"SEMVERX_LOG_LEVEL":      "debug",
+		"SEMVERX_IDLE_TIMEOUT":   "90s",
+	}
+	cfg, err := loadConfig([]string{"-service-id", "flag-service", "-version", "v2.stable.1.rc.0.stable"},
+		func(k string) string { return env[k] }, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Addr != ":4000" {
+		t.Errorf("Addr = %q, want env value", cfg.Addr)
+	}
+	if cfg.ServiceID != "flag-service" {
+		t.Errorf("ServiceID = %q, want flag to override env", cfg.ServiceID)
+	}
+	if cfg.Version.String() != "v2.stable.1.rc.0.stable" {
+		t.Errorf("Version = %s", cfg.Version)
+	}
+	if cfg.LogLevel != slog.LevelDebug {
+		t.Errorf("LogLevel = %s, want env value", cfg.LogLevel)
+	}
+	if cfg.ShutdownGrace != 3*time.Second {
+		t.Errorf("ShutdownGrace = %s, want env value", cfg.ShutdownGrace)
+	}
+	if cfg.IdleTimeout != 90*time.Second || cfg.ReadTimeout != defaultReadTimeout {
+		t.Errorf("IdleTimeout = %s, ReadTimeout = %s", cfg.IdleTimeout, cfg.ReadTimeout)
+	}
+
+	cfg, err = loadConfig([]string{"-supported", "v1.stable.0.stable.0.stable, v1.stable.1.beta.0.stable"}, func(string) string { return "" }, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Supported) != 2 || cfg.Supported[1].String() != "v1.stable.1.beta.0.stable" {
+		t.Errorf("Supported = %v", cfg.Supported)
+	}
+
+	cfg, err = loadConfig([]string{"-peers", "rust-service=http://127.0.0.1:3001/, python-service=http://127.0.0.1:3003"}, func(string) string { return "" }, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.PeerURLs["rust-service"] != "http://127.0.0.1:3001" || cfg.PeerURLs["python-service"] != "http://127.0.0.1:3003" {
+		t.Errorf("PeerURLs = %v", cfg.PeerURLs)
+	}
+
+	cfg, err = loadConfig([]string{"-min-stability=rc"}, func(string) string { return "" }, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Stability.MinChannel != semverx.ChannelRC {
+		t.Errorf("Stability = %+v", cfg.Stability)
+	}
+
+	cfg, err = loadConfig([]string{"-rate-limit", "2.5", "-anon-rate-burst", "3"},
+		func(k string) string { return map[string]string{"SEMVERX_RATE_BURST": "7"}[k] }, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.RateLimit != (RateLimit{Rate: 2.5, Burst: 7}) || cfg.AnonRateLimit != (RateLimit{Rate: defaultAnonRateLimit, Burst: 3}) {
+		t.Errorf("RateLimit = %+v, AnonRateLimit = %+v", cfg.RateLimit, cfg.AnonRateLimit)
+	}
+
+	cfg, err = loadConfig([]string{"-bus-overflow", "block"}, func(string) string { return "" }, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.BusOverflow != OverflowBlock {
+		t.Errorf("BusOverflow = %s, want block", cfg.BusOverflow)
+	}
+
+	cfg, err = loadConfig([]string{"-cors-origins", "https://dash.example, http://localhost:8080", "-cors-credentials"}, func(string) string { return "" }, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"https://dash.example", "http://localhost:8080"}; !reflect.DeepEqual(cfg.CORS.AllowedOrigins, want) || !cfg.CORS.AllowCredentials {
+		t.Errorf("CORS = %+v", cfg.CORS)
+	}
+
+	cfg, err = loadConfig([]string{"-allow-peers", "rust-service,python-service"},
+		func(k string) string { return map[string]string{"SEMVERX_DENY_PEERS": "python-service"}[k] }, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (ACL{Allow: []string{"rust-service", "python-service"}, Deny: []string{"python-service"}}); !reflect.DeepEqual(cfg.ACL, want) {
+		t.Errorf("ACL = %+v", cfg.ACL)
+	}
+
+	cfg, err = loadConfig([]string{"-peer-store", "/var/lib/semverx/peers.json"},
+		func(k string) string { return map[string]string{"SEMVERX_PEER_SNAPSHOT_INTERVAL": "5s"}[k] }, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.PeerStore != "/var/lib/semverx/peers.json" || cfg.PeerSnapshotInterval != 5*time.Second {
+		t.Errorf("PeerStore = %q, PeerSnapshotInterval = %s", cfg.PeerStore, cfg.PeerSnapshotInterval)
+	}
+
+	if want := []string{"Content-Type", "X-Service-Id"}; !reflect.DeepEqual(DefaultConfig().RequiredHeaders, want) {
+		t.Errorf("default RequiredHeaders = %q, want %q", DefaultConfig().RequiredHeaders, want)
+	}
+	cfg, err = loadConfig([]string{"-require-headers", "content-type, idempotency-key"}, func(string) string { return "" }, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"Content-Type", "Idempotency-Key"}; !reflect.DeepEqual(cfg.RequiredHeaders, want) {
+		t.Errorf("RequiredHeaders = %q, want %q", cfg.RequiredHeaders, want)
+	}
+	cfg, err = loadConfig([]string{"-require-headers="}, func(string) string { return "" }, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.RequiredHeaders != nil {
+		t.Errorf("empty -require-headers: RequiredHeaders = %q, want none", cfg.RequiredHeaders)
+	}
+
+	cfg, err = loadConfig(nil, func(string) string { return "" }, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(cfg, DefaultConfig()) {
+		t.Errorf("loadConfig with no input = %+v, want defaults", cfg)
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	noenv := func(string) string { return "" }
+	for _, args := range [][]string{
+		{"-version", "1.0.0"},
+		{"-service-id", ""},
+		{"-unknown"},
+		{"-supported", "v1.stable.0.stable.0.stable,bogus"},
+		{"-shutdown-grace", "soon"},
+		{"-shutdown-grace", "-1s"},
+		{"-max-batch", "0"},
+		{"-max-hops", "0"},
+		{"-log-level", "loud"},
+		{"-peers", "rust-service"},
+		{"-min-stability", "nightly"},
+		{"-max-body", "-1"},
+		{"-rate-limit", "-1"},
+		{"-bus-buffer", "-1"},
+		{"-stream-ping", "-1s"},
+		{"-peer-snapshot-interval", "0s"},
+		{"-idempotency-ttl", "-1m"},
+		{"-read-timeout", "-1s"},
+		{"-bus-overflow", "spill"},
+		{"-cors-origins", "*", "-cors-credentials"},
+		{"-anon-rate-burst", "0"},
+	} {
+		if _, err := loadConfig(args, noenv, io.Discard); err == nil {
+			t.Errorf("loadConfig(%q, io.Discard) succeeded, want error", args)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/obinexus/rust-semverx/MVP/examples/drivers/semverx"
+	"github.com/obinexus/rust-semverx/MVP/examples/drivers/semverxpb"
+)
+
+const mediaJSON = "application/json"
+
+// supportedMediaTypes lists the response encodings in order of preference.
+var supportedMediaTypes = []string{mediaJSON, semverxpb.ContentType}
+
+// protoBody is a response body with a protobuf encoding. proto fails for
+// values the schema cannot represent.
+type protoBody interface {
+	proto() (proto.Message, error)
+}
+
+// negotiateMediaType picks the supported media type the Accept header
+// ranks highest. An absent header accepts JSON; ties go to the earlier
+// entry of supportedMediaTypes. It reports false when nothing supported is
+// acceptable.
+func negotiateMediaType(accept string) (string, bool) {
+	if strings.TrimSpace(accept) == "" {
+		return mediaJSON, true
+	}
+	best, bestQ := "", 0.0
+	for _, mt := range supportedMediaTypes {
+		if q := acceptQuality(accept, mt); q > bestQ {
+			best, bestQ = mt, q
+		}
+	}
+	return best, best != ""
+}
+
+// acceptQuality returns the q-value accept assigns to mediaType, using the
+// most specific matching media range.
+func acceptQuality(accept, mediaType string) float64 {
+	typ, _, _ := strings.Cut(mediaType, "/")
+	q, specificity := 0.0, -1
+	for _, rng := range strings.Split(accept, ",") {
+		mt, params, err := mime.ParseMediaType(strings.TrimSpace(rng))
+		if err != nil {
+			continue
+		}
+		var spec int
+		switch {
+		case mt == mediaType:
+			spec = 2
+		case mt == typ+"/*":
+			spec = 1
+		case mt == "*/*":
+			spec = 0
+		default:
+			continue
+		}
+		if spec <= specificity {
+			continue
+		}
+		rq := 1.0
+		if s, ok := params["q"]; ok {
+			if rq, err = strconv.ParseFloat(s, 64); err != nil {
+				rq = 0
+			}
+		}
+		q, specificity = rq, spec
+	}
+	return q
+}
+
+// writeNegotiated writes v as JSON or protobuf, whichever the request
+// accepts, or answers 406 listing the supported media types.
+func writeNegotiated(w http.ResponseWriter, r *http.Request, status int, v protoBody) {
+	mt, ok := negotiateMediaType(r.Header.Get("Accept"))
+	if !ok {
+		writeJSON(w, http.StatusNotAcceptable, map[string]interface{}{
+			"error":     "no acceptable media type",
+			"supported": supportedMediaTypes,
+		})
+		return
+	}
+	w.Header().Add("Vary", "Accept")
+	if mt == semverxpb.ContentType {
+		pb, err := v.proto()
+		var b []byte
+		if err == nil {
+			b, err = proto.Marshal(pb)
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "cannot encode as protobuf: "+err.Error())
+			return
+		}
+		w.Header().Set("Content-Type", semverxpb.ContentType)
+		w.WriteHeader(status)
+		w.Write(b)
+		return
+	}
+	writeJSON(w, status, v)
+}
+
+// isProtobuf reports whether contentType names the protobuf encoding.
+func isProtobuf(contentType string) bool {
+	mt, _, err := mime.ParseMediaType(contentType)
+	return err == nil && mt == semverxpb.ContentType
+}
+
+// decodeServiceMessage decodes a /message body in the encoding named by
+// contentType: protobuf for application/x-protobuf, JSON otherwise.
+func decodeServiceMessage(contentType string, body []byte) (ServiceMessage, error) {
+	var msg ServiceMessage
+	if !isProtobuf(contentType) {
+		err := json.Unmarshal(body, &msg)
+		return msg, err
+	}
+	var pb semverxpb.ServiceMessage
+	if err := proto.Unmarshal(body, &pb); err != nil {
+		return msg, err
+	}
+	if pb.Version != "" {
+		v, err := semverx.ParseLenient(pb.Version)
+		if err != nil {
+			return msg, err
+		}
+		msg.Version, msg.RawVersion = v, pb.Version
+	}
+	if len(pb.Payload) > 0 {
+		if !json.Valid(pb.Payload) {
+			return msg, errors.New("payload is not valid JSON")
+		}
+		msg.Payload = json.RawMessage(pb.Payload)
+	}
+	msg.ServiceID, msg.Timestamp, msg.Signature, msg.Visited = pb.ServiceId, pb.Timestamp, pb.Signature, pb.Visited
+	msg.MessageType = pb.MessageType
+	return msg, nil
+}
+
+func (v VersionInfo) proto() (proto.Message, error) {
+	var nums [3]uint32
+	for i, n := range [...]int{v.Major, v.Minor, v.Patch} {
+		if n < 0 || uint64(n) > math.MaxUint32 {
+			return nil, fmt.Errorf("version %s: component %d does not fit the uint32 protobuf field", v.Raw, n)
+		}
+		nums[i] = uint32(n)
+	}
+	return &semverxpb.Version{
+		Major:        nums[0],
+		MajorChannel: v.MajorChannel,
+		Minor:        nums[1],
+		MinorChannel: v.MinorChannel,
+		Patch:        nums[2],
+		PatchChannel: v.PatchChannel,
+		Raw:          v.Raw,
+	}, nil
+}
+
+func (h HealthStatus) proto() (proto.Message, error) {
+	pb := &semverxpb.Health{ServiceId: h.ServiceID, Healthy: h.Healthy, Version: h.Version}
+	for _, c := range h.Checks {
+		pb.Checks = append(pb.Checks, &semverxpb.HealthCheck{Name: c.Name, Ok: c.OK, Error: c.Error})
+	}
+	return pb, nil
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/obinexus/rust-semverx/MVP/examples/drivers/semverx"
+	"github.com/obinexus/rust-semverx/MVP/examples/drivers/semverxpb"
+)
+
+// protoServiceMessage is the protobuf form of msg.
+func protoServiceMessage(msg ServiceMessage) *semverxpb.ServiceMessage {
+	pb := &semverxpb.ServiceMessage{
+		ServiceId: msg.ServiceID,
+		Payload:   msg.Payload,
+		Timestamp: msg.Timestamp,
+		Signature: msg.Signature,
+		Visited:   msg.Visited,
+
+		MessageType: msg.MessageType,
+	}
+	if !msg.Version.IsZero() {
+		pb.Version = msg.Version.String()
+	}
+	return pb
+}
+
+func marshalProto(t *testing.T, m proto.Message) []byte {
+	t.Helper()
+	b, err := proto.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestNegotiateMediaType(t *testing.T) {
+	for _, tc := range []struct {
+		accept string
+		want   string
+		ok     bool
+	}{
+		{"", mediaJSON, true},
+		{"*/*", mediaJSON, true},
+		{"application/json", mediaJSON, true},
+		{"application/x-protobuf", semverxpb.ContentType, true},
+		{"application/x-protobuf, application/json", mediaJSON, true},
+		{"application/json;q=0.5, application/x-protobuf", semverxpb.ContentType, true},
+		{"application/*;q=0.2, application/x-protobuf;q=0.9", semverxpb.ContentType, true},
+		{"*/*, application/json;q=0", semverxpb.ContentType, true},
+		{"text/html", "", false},
+		{"application/json;q=0", "", false},
+	} {
+		got, ok := negotiateMediaType(tc.accept)
+		if got != tc.want || ok != tc.ok {
+			t.Errorf("negotiateMediaType(%q) = %q, %v; want %q, %v", tc.accept, got, ok, tc.want, tc.ok)
+		}
+	}
+}
+
+func getWithAccept(h http.Handler, path, accept string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestVersionProtobuf(t *testing.T) {
+	rec := getWithAccept(newTestServer().Routes(), "/version", semverxpb.ContentType)
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != semverxpb.ContentType {
+		t.Fatalf("status = %d, Content-Type = %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	var v semverxpb.Version
+	if err := proto.Unmarshal(rec.Body.Bytes(), &v); err != nil {
+		t.Fatal(err)
+	}
+	want := &semverxpb.Version{Major: 1, MajorChannel: "stable", MinorChannel: "stable", PatchChannel: "stable", Raw: "v1.stable.0.stable.0.stable"}
+	if !proto.Equal(&v, want) {
+		t.Errorf("got %v, want %v", &v, want)
+	}
+}
+
+func TestVersionProtobufOverflow(t *testing.T) {
+	if math.MaxInt <= math.MaxUint32 {
+		t.Skip("version numbers cannot exceed uint32 where int is 32 bits")
+	}
+	if _, err := newVersionInfo(semverx.MustParseVersion("v4294967296.stable.0.stable.0.stable")).proto(); err == nil {
+		t.Error("proto() accepted a major version above MaxUint32")
+	}
+	cfg := DefaultConfig()
+	cfg.Version = semverx.MustParseVersion("v1.stable.4294967296.stable.0.stable")
+	h := NewServer(cfg, WithLogOutput(io.Discard)).Routes()
+	if rec := getWithAccept(h, "/version", semverxpb.ContentType); rec.Code != http.StatusInternalServerError {
+		t.Errorf("protobuf: status = %d, want 500", rec.Code)
+	}
+	if rec := getWithAccept(h, "/version", mediaJSON); rec.Code != http.StatusOK {
+		t.Errorf("JSON: status = %d, want 200", rec.Code)
+	}
+}
+
+func TestHealthProtobuf(t *testing.T) {
+	rec := getWithAccept(newTestServer().Routes(), "/health/ready", semverxpb.ContentType)
+	var h semverxpb.Health
+	if err := proto.Unmarshal(rec.Body.Bytes(), &h); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusOK || !h.Healthy || h.ServiceId != "go-service" || h.Version != "v1.stable.0.stable.0.stable" {
+		t.Errorf("status = %d, body = %v", rec.Code, &h)
+	}
+}
+
+func TestNotAcceptable(t *testing.T) {
+	h := newTestServer().Routes()
+	for _, path := range []string{"/version", "/health"} {
+		rec := getWithAccept(h, path, "text/html")
+		if rec.Code != http.StatusNotAcceptable {
+			t.Errorf("%s: status = %d, want 406", path, rec.Code)
+			continue
+		}
+		var body struct {
+			Supported []string `json:"supported"`
+		}
+		if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+		if strings.Join(body.Supported, ",") != mediaJSON+","+semverxpb.ContentType {
+			t.Errorf("%s: supported = %v", path, body.Supported)
+		}
+	}
+}
+
+func TestMessageProtobuf(t *testing.T) {
+	srv := newTestServer()
+	h := srv.Routes()
+	post := func(msg *semverxpb.ServiceMessage) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/message", bytes.NewReader(marshalProto(t, msg)))
+		req.Header.Set("Content-Type", semverxpb.ContentType)
+		req.Header.Set(serviceIDHeader, msg.ServiceId)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	msg := ServiceMessage{ServiceID: "pb-peer", Payload: json.RawMessage(`{"k":1}`), Timestamp: 1}
+	msg.Version = srv.cfg.Version
+	if rec := post(protoServiceMessage(msg)); rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", rec.Code, rec.Body)
+	}
+	if _, ok := srv.peers.Lookup("pb-peer"); !ok {
+		t.Error("protobuf sender was not registered")
+	}
+
+	for name, pb := range map[string]*semverxpb.ServiceMessage{
+		"bad version":  {ServiceId: "x", Version: "1.0.0", Timestamp: 1},
+		"bad payload":  {ServiceId: "x", Version: "v1.stable.0.stable.0.stable", Payload: []byte("{"), Timestamp: 1},
+		"no version":   {ServiceId: "x", Timestamp: 1},
+		"no timestamp": {ServiceId: "x", Version: "v1.stable.0.stable.0.stable"},
+	} {
+		if rec := post(pb); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", name, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultCORSMethods = "GET,POST"
+	defaultCORSHeaders = "Accept,Content-Type,X-Service-ID"
+
+	corsMaxAge = 600 // seconds browsers may cache a preflight result
+)
+
+// CORS configures cross-origin access for browser clients. With no
+// AllowedOrigins every cross-origin request is refused.
+type CORS struct {
+	// AllowedOrigins lists the origins, such as "https://dash.example",
+	// allowed to call the service. "*" allows any origin and must be
+	// listed explicitly.
+	AllowedOrigins []string
+	// AllowedMethods and AllowedHeaders are answered to preflight
+	// requests.
+	AllowedMethods []string
+	AllowedHeaders []string
+	// AllowCredentials lets browsers send cookies and HTTP auth. It
+	// cannot be combined with the "*" origin.
+	AllowCredentials bool
+}
+
+func (c CORS) enabled() bool { return len(c.AllowedOrigins) > 0 }
+
+// allowOrigin returns the Access-Control-Allow-Origin value for origin,
+// or "" when origin is not allowed.
+func (c CORS) allowOrigin(origin string) string {
+	for _, o := range c.AllowedOrigins {
+		if o == "*" {
+			return "*"
+		}
+		if strings.EqualFold(o, origin) {
+			return origin
+		}
+	}
+	return ""
+}
+
+// withCORS adds CORS headers for allowed origins and answers their
+// preflight requests. Requests from other origins are served without any
+// CORS headers, so browsers withhold the response from the calling page.
+func (s *Server) withCORS(next http.Handler) http.Handler {
+	c := s.cfg.CORS
+	if !c.enabled() {
+		return next
+	}
+	methods := strings.Join(append(append([]string(nil), c.AllowedMethods...), http.MethodOptions), ", ")
+	headers := strings.Join(c.AllowedHeaders, ", ")
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		h := w.Header()
+		h.Add("Vary", "Origin")
+		allowed := c.allowOrigin(origin)
+		if allowed == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		h.Set("Access-Control-Allow-Origin", allowed)
+		if c.AllowCredentials {
+			h.Set("Access-Control-Allow-Credentials", "true")
+		}
+
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			h.Add("Vary", "Access-Control-Request-Method")
+			h.Add("Vary", "Access-Control-Request-Headers")
+			h.Set("Access-Control-Allow-Methods", methods)
+			if headers != "" {
+				h.Set("Access-Control-Allow-Headers", headers)
+			}
+			h.Set("Access-Control-Max-Age", strconv.Itoa(corsMaxAge))
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		h.Set("Access-Control-Expose-Headers", requestIDHeader)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// splitList splits a comma-separated flag value, dropping empty entries.
+func splitList(s string) []string {
+	var out []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func corsServer(cors CORS) http.Handler {
+	cfg := DefaultConfig()
+	cors.AllowedMethods, cors.AllowedHeaders = cfg.CORS.AllowedMethods, cfg.CORS.AllowedHeaders
+	cfg.CORS = cors
+	return NewServer(cfg, WithLogOutput(io.Discard)).Routes()
+}
+
+func TestCORSPreflight(t *testing.T) {
+	h := corsServer(CORS{AllowedOrigins: []string{"https://dash.example"}, AllowCredentials: true})
+	req := httptest.NewRequest(http.MethodOptions, "/peers", nil)
+	req.Header.Set("Origin", "https://dash.example")
+	req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+	req.Header.Set("Access-Control-Request-Headers", "x-service-id")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("status = %d, want 204", rec.Code)
+	}
+	for k, want := range map[string]string{
+		"Access-Control-Allow-Origin":      "https://dash.example",
+		"Access-Control-Allow-Credentials": "true",
+		"Access-Control-Allow-Methods":     "GET, POST, OPTIONS",
+		"Access-Control-Allow-Headers":     "Accept, Content-Type, X-Service-ID",
+		"Access-Control-Max-Age":           "600",
+	} {
+		if got := rec.Header().Get(k); got != want {
+			t.Errorf("%s = %q, want %q", k, got, want)
+		}
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/version", nil)
+	req.Header.Set("Origin", "https://dash.example")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || rec.Header().Get("Access-Control-Allow-Origin") != "https://dash.example" {
+		t.Errorf("GET /version: status = %d, headers = %v", rec.Code, rec.Header())
+	}
+	if rec.Header().Get("Vary") != "Origin" {
+		t.Errorf("Vary = %q, want Origin", rec.Header().Get("Vary"))
+	}
+}
+
+func TestCORSDisallowedOrigin(t *testing.T) {
+	for name, cors := range map[string]CORS{
+		"disabled":  {},
+		"allowlist": {AllowedOrigins: []string{"https://dash.example"}},
+	} {
+		h := corsServer(cors)
+		for _, method := range []string{http.MethodOptions, http.MethodGet} {
+			req := httptest.NewRequest(method, "/health", nil)
+			req.Header.Set("Origin", "https://evil.example")
+			req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			for k := range rec.Header() {
+				if strings.HasPrefix(k, "Access-Control-") {
+					t.Errorf("%s %s: unexpected %s header", name, method, k)
+				}
+			}
+		}
+	}
+}
+
+func TestCORSWildcard(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/health", nil)
+	req.Header.Set("Origin", "https://anywhere.example")
+	rec := httptest.NewRecorder()
+	corsServer(CORS{AllowedOrigins: []string{"*"}}).ServeHTTP(rec, req)
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Errorf("Access-Control-Allow-Origin = %q, want *", got)
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Credentials"); got != "" {
+		t.Errorf("Access-Control-Allow-Credentials = %q with a wildcard origin", got)
+	}
+}
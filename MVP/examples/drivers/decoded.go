@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+)
+
+// decodedMessage is a /message body, read and decoded once by withMessage
+// for the middleware and handler behind it.
+type decodedMessage struct {
+	body []byte
+	msg  ServiceMessage
+	// err is why body did not decode, in which case msg is unset. The
+	// middleware passes such requests on for the handler to report.
+	err error
+}
+
+func newDecodedMessage(contentType string, body []byte) *decodedMessage {
+	d := &decodedMessage{body: body}
+	d.msg, d.err = decodeServiceMessage(contentType, body)
+	return d
+}
+
+type decodedMessageKey struct{}
+
+// withMessage reads the request body and decodes it, in the encoding named
+// by Content-Type, into the request context, where requestMessage finds it.
+// A body that cannot be read is answered straight away by writeBodyError.
+func (s *Server) withMessage(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeBodyError(w, "reading body", err)
+			return
+		}
+		d := newDecodedMessage(r.Header.Get("Content-Type"), body)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), decodedMessageKey{}, d)))
+	})
+}
+
+// requestMessage returns the body withMessage decoded for r, or nil when r
+// did not pass through withMessage.
+func requestMessage(r *http.Request) *decodedMessage {
+	d, _ := r.Context().Value(decodedMessageKey{}).(*decodedMessage)
+	return d
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWithMessage(t *testing.T) {
+	srv := newTestServer()
+	var got *decodedMessage
+	h := srv.withMessage(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = requestMessage(r)
+	}))
+
+	body := `{"service_id":"rust-service","version":"v1.stable.0.stable.0.stable","timestamp":1}`
+	h.ServeHTTP(httptest.NewRecorder(), newMessageRequest("/message", body))
+	if got == nil || got.err != nil || got.msg.ServiceID != "rust-service" || string(got.body) != body {
+		t.Errorf("decoded = %+v", got)
+	}
+
+	got = nil
+	h.ServeHTTP(httptest.NewRecorder(), newMessageRequest("/message", `not json`))
+	if got == nil || got.err == nil || string(got.body) != "not json" {
+		t.Errorf("undecodable body: decoded = %+v, want its error kept", got)
+	}
+
+	if requestMessage(httptest.NewRequest(http.MethodPost, "/message", strings.NewReader(body))) != nil {
+		t.Error("requestMessage found a message on a request that was not decoded")
+	}
+}
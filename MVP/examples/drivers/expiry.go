@@ -0,0 +1,44 @@
+package main
+
+import (
+	"container/heap"
+	"time"
+)
+
+// expiryQueue orders keys by the time they expire, so that a cache can drop
+// its expired entries without scanning all of them. It holds no lock of its
+// own; callers guard it with the lock of the map it indexes. A key may be
+// queued more than once, so callers check the entry itself before dropping
+// it.
+type expiryQueue []expiryItem
+
+type expiryItem struct {
+	key string
+	at  time.Time
+}
+
+func (q expiryQueue) Len() int           { return len(q) }
+func (q expiryQueue) Less(i, j int) bool { return q[i].at.Before(q[j].at) }
+func (q expiryQueue) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+func (q *expiryQueue) Push(x any)        { *q = append(*q, x.(expiryItem)) }
+
+func (q *expiryQueue) Pop() any {
+	old := *q
+	it := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return it
+}
+
+// add queues key to expire at.
+func (q *expiryQueue) add(key string, at time.Time) {
+	heap.Push(q, expiryItem{key: key, at: at})
+}
+
+// popExpired removes and returns a key whose time is before now, reporting
+// false when there is none.
+func (q *expiryQueue) popExpired(now time.Time) (string, bool) {
+	if len(*q) == 0 || !(*q)[0].at.Before(now) {
+		return "", false
+	}
+	return heap.Pop(q).(expiryItem).key, true
+}
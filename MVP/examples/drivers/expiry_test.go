@@ -0,0 +1,57 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/obinexus/rust-semverx/MVP/examples/drivers/semverx"
+)
+
+func TestExpiryQueue(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	var q expiryQueue
+	q.add("c", now.Add(3*time.Second))
+	q.add("a", now.Add(time.Second))
+	q.add("b", now.Add(2*time.Second))
+
+	if k, ok := q.popExpired(now.Add(time.Second)); ok {
+		t.Fatalf("popped %q at its expiry time, want it kept until after", k)
+	}
+	var got []string
+	for {
+		k, ok := q.popExpired(now.Add(2500 * time.Millisecond))
+		if !ok {
+			break
+		}
+		got = append(got, k)
+	}
+	if len(got) != 2 || got[0] != "a" || got[1] != "b" {
+		t.Errorf("expired = %v, want [a b]", got)
+	}
+	if q.Len() != 1 {
+		t.Errorf("%d keys left, want 1", q.Len())
+	}
+}
+
+func TestPeerRegistryPrunesRefreshedPeer(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	r := NewPeerRegistry(time.Minute)
+	r.now = func() time.Time { return now }
+	v := semverx.MustParseVersion("v1.stable.0.stable.0.stable")
+	r.Register("a", v)
+	r.Register("b", v)
+
+	now = now.Add(50 * time.Second)
+	r.Register("a", v)
+	now = now.Add(20 * time.Second)
+	if got := r.List(); len(got) != 1 || got[0].ServiceID != "a" {
+		t.Fatalf("List = %+v, want only a", got)
+	}
+	if len(r.expiry) != 1 {
+		t.Errorf("queue holds %d entries for 1 peer", len(r.expiry))
+	}
+	now = now.Add(time.Minute)
+	if got := r.List(); len(got) != 0 {
+		t.Errorf("List = %+v, want none", got)
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/obinexus/rust-semverx/MVP/examples/drivers/semverx"
+)
+
+// Forwarder relays accepted messages to known peers whose version is
+// compatible with the local one.
+//
+// Loops are prevented through ServiceMessage.Visited: every relay appends
+// its own service ID, peers already listed (and the origin) are skipped, and
+// a message that has visited MaxHops services is not relayed further.
+type Forwarder struct {
+	Self     string
+	Local    semverx.Version
+	Registry *PeerRegistry
+	// URLs maps peer service IDs to their base URL, e.g.
+	// "http://127.0.0.1:3001".
+	URLs    map[string]string
+	Client  *http.Client
+	MaxHops int
+	// Timeout bounds each relay request. Zero relies on ctx alone.
+	Timeout time.Duration
+	// Tracer, when set, records a client span per relay, a child of the
+	// span in ctx, and propagates it to the peer.
+	Tracer trace.Tracer
+}
+
+// ForwardResult reports the outcome of relaying to one peer.
+type ForwardResult struct {
+	ServiceID string
+	Err       error
+}
+
+// Forward relays msg to every eligible peer in parallel and waits for all
+// of them. Cancelling ctx aborts outstanding relays.
+func (f *Forwarder) Forward(ctx context.Context, msg ServiceMessage) []ForwardResult {
+	if len(msg.Visited) >= f.MaxHops {
+		return nil
+	}
+	skip := map[string]bool{f.Self: true, msg.ServiceID: true}
+	for _, id := range msg.Visited {
+		skip[id] = true
+	}
+	out := msg
+	out.Visited = append(append([]string(nil), msg.Visited...), f.Self)
+	body, err := json.Marshal(out)
+	if err != nil {
+		return []ForwardResult{{Err: err}}
+	}
+
+	var (
+		mu      sync.Mutex
+		results []ForwardResult
+		wg      sync.WaitGroup
+	)
+	for _, p := range f.Registry.List() {
+		url, ok := f.URLs[p.ServiceID]
+		if !ok || skip[p.ServiceID] || !semverx.Compatible(f.Local, p.Version) {
+			continue
+		}
+		wg.Add(1)
+		go func(id, url string) {
+			defer wg.Done()
+			err := f.send(ctx, id, url, out.ServiceID, body)
+			mu.Lock()
+			results = append(results, ForwardResult{ServiceID: id, Err: err})
+			mu.Unlock()
+		}(p.ServiceID, url)
+	}
+	wg.Wait()
+	return results
+}
+
+func (f *Forwarder) send(ctx context.Context, id, baseURL, sender string, body []byte) (err error) {
+	if f.Tracer != nil {
+		var span trace.Span
+		ctx, span = f.Tracer.Start(ctx, "forward", trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(attrPeer.String(id), attribute.String("server.address", baseURL)))
+		defer func() {
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			span.End()
+		}()
+	}
+	if f.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, f.Timeout)
+		defer cancel()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, baseURL+"/message?forward=true", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(serviceIDHeader, sender)
+	traceContext.Inject(ctx, propagation.HeaderCarrier(req.Header))
+	resp, err := f.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("peer responded %s", resp.Status)
+	}
+	return nil
+}
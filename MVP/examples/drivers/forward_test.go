@@ -0,0 +1,178 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/obinexus/rust-semverx/MVP/examples/drivers/semverx"
+)
+
+// stubPeer records every message posted to it.
+type stubPeer struct {
+	*httptest.Server
+	mu   sync.Mutex
+	msgs []ServiceMessage
+}
+
+func newStubPeer(t *testing.T, h func(w http.ResponseWriter)) *stubPeer {
+	p := &stubPeer{}
+	p.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg ServiceMessage
+		json.NewDecoder(r.Body).Decode(&msg)
+		p.mu.Lock()
+		p.msgs = append(p.msgs, msg)
+		p.mu.Unlock()
+		if h != nil {
+			h(w)
+		}
+	}))
+	t.Cleanup(p.Close)
+	return p
+}
+
+func (p *stubPeer) received() []ServiceMessage {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]ServiceMessage(nil), p.msgs...)
+}
+
+func TestForwardToCompatiblePeers(t *testing.T) {
+	compatible := newStubPeer(t, nil)
+	incompatible := newStubPeer(t, nil)
+	origin := newStubPeer(t, nil)
+
+	cfg := DefaultConfig()
+	cfg.PeerURLs = map[string]string{
+		"compatible":   compatible.URL,
+		"incompatible": incompatible.URL,
+		"origin":       origin.URL,
+	}
+	srv := NewServer(cfg, WithLogOutput(io.Discard))
+	srv.peers.Register("compatible", semverx.MustParseVersion("v1.stable.1.stable.0.stable"))
+	srv.peers.Register("incompatible", semverx.MustParseVersion("v2.stable.0.stable.0.stable"))
+	srv.peers.Register("unaddressed", semverx.MustParseVersion("v1.stable.0.stable.0.stable"))
+
+	rec := httptest.NewRecorder()
+	srv.Routes().ServeHTTP(rec, newMessageRequest("/message?forward=true",
+		`{"service_id":"origin","version":"v1.stable.0.stable.0.stable","payload":{"k":"v"},"timestamp":1}`))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", rec.Code, rec.Body)
+	}
+
+	got := compatible.received()
+	if len(got) != 1 {
+		t.Fatalf("compatible peer got %d messages", len(got))
+	}
+	if got[0].ServiceID != "origin" || string(got[0].Payload) != `{"k":"v"}` {
+		t.Errorf("forwarded message = %+v", got[0])
+	}
+	if len(got[0].Visited) != 1 || got[0].Visited[0] != cfg.ServiceID {
+		t.Errorf("Visited = %v, want [%s]", got[0].Visited, cfg.ServiceID)
+	}
+	if n := len(incompatible.received()); n != 0 {
+		t.Errorf("incompatible peer got %d messages", n)
+	}
+	if n := len(origin.received()); n != 0 {
+		t.Errorf("origin got its own message back %d times", n)
+	}
+}
+
+func TestForwardRequiresQueryParam(t *testing.T) {
+	peer := newStubPeer(t, nil)
+	cfg := DefaultConfig()
+	cfg.PeerURLs = map[string]string{"peer": peer.URL}
+	srv := NewServer(cfg, WithLogOutput(io.Discard))
+	srv.peers.Register("peer", cfg.Version)
+
+	postMessage(t, srv, `{"service_id":"origin","version":"v1.stable.0.stable.0.stable","timestamp":1}`)
+	if n := len(peer.received()); n != 0 {
+		t.Errorf("forwarded without ?forward=true: %d messages", n)
+	}
+}
+
+func TestForwardHopLimit(t *testing.T) {
+	peer := newStubPeer(t, nil)
+	f := &Forwarder{
+		Self:     "self",
+		Local:    semverx.MustParseVersion("v1.stable.0.stable.0.stable"),
+		Registry: NewPeerRegistry(0),
+		URLs:     map[string]string{"peer": peer.URL, "visited": peer.URL},
+		Client:   http.DefaultClient,
+		MaxHops:  2,
+	}
+	f.Registry.Register("peer", f.Local)
+	f.Registry.Register("visited", f.Local)
+
+	msg := ServiceMessage{ServiceID: "origin", Version: f.Local, Timestamp: 1, Visited: []string{"a", "b"}}
+	if res := f.Forward(context.Background(), msg); len(res) != 0 {
+		t.Errorf("message at hop limit forwarded: %+v", res)
+	}
+
+	msg.Visited = []string{"visited"}
+	res := f.Forward(context.Background(), msg)
+	if len(res) != 1 || res[0].ServiceID != "peer" || res[0].Err != nil {
+		t.Errorf("results = %+v", res)
+	}
+}
+
+func TestForwardMeshNoLoop(t *testing.T) {
+	// Two real nodes that both forward to each other.
+	var a, b *Server
+	aTS := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { a.Routes().ServeHTTP(w, r) }))
+	defer aTS.Close()
+	bTS := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { b.Routes().ServeHTTP(w, r) }))
+	defer bTS.Close()
+
+	newNode := func(id, peer, url string) *Server {
+		cfg := DefaultConfig()
+		cfg.ServiceID = id
+		cfg.PeerURLs = map[string]string{peer: url}
+		srv := NewServer(cfg, WithLogOutput(io.Discard))
+		srv.peers.Register(peer, cfg.Version)
+		return srv
+	}
+	a = newNode("a", "b", bTS.URL)
+	b = newNode("b", "a", aTS.URL)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, aTS.URL+"/message?forward=true",
+		strings.NewReader(`{"service_id":"origin","version":"v1.stable.0.stable.0.stable","timestamp":1}`))
+	req.Header.Set("Content-Type", mediaJSON)
+	req.Header.Set(serviceIDHeader, "origin")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("forwarding loop did not terminate: %v", err)
+	}
+	resp.Body.Close()
+	if _, ok := b.peers.Lookup("origin"); !ok {
+		t.Error("b never received the forwarded message")
+	}
+}
+
+func TestForwardCancelled(t *testing.T) {
+	block := make(chan struct{})
+	defer close(block)
+	peer := newStubPeer(t, func(w http.ResponseWriter) { <-block })
+	f := &Forwarder{
+		Self:     "self",
+		Local:    semverx.MustParseVersion("v1.stable.0.stable.0.stable"),
+		Registry: NewPeerRegistry(0),
+		URLs:     map[string]string{"peer": peer.URL},
+		Client:   http.DefaultClient,
+		MaxHops:  4,
+		Timeout:  50 * time.Millisecond,
+	}
+	f.Registry.Register("peer", f.Local)
+	res := f.Forward(context.Background(), ServiceMessage{ServiceID: "origin", Timestamp: 1})
+	if len(res) != 1 || res[0].Err == nil {
+		t.Errorf("results = %+v, want a timeout error", res)
+	}
+}
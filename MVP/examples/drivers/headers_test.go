@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/obinexus/rust-semverx/MVP/examples/drivers/semverxpb"
+)
+
+func TestRequiredHeaders(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.RequiredHeaders = []string{"Content-Type", "X-Service-Id"}
+	srv := NewServer(cfg, WithLogOutput(io.Discard))
+	h := srv.Routes()
+	const body = `{"service_id":"rust-service","version":"v1.stable.0.stable.0.stable","timestamp":1}`
+
+	for name, tc := range map[string]struct {
+		contentType, sender string
+		want                int
+	}{
+		"json":             {"application/json; charset=utf-8", "rust-service", http.StatusOK},
+		"no content type":  {"", "rust-service", http.StatusUnsupportedMediaType},
+		"text content":     {"text/plain", "rust-service", http.StatusUnsupportedMediaType},
+		"no sender header": {"application/json", "", http.StatusBadRequest},
+		"sender mismatch":  {"application/json", "python-service", http.StatusBadRequest},
+	} {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/message", strings.NewReader(body))
+			if tc.contentType != "" {
+				req.Header.Set("Content-Type", tc.contentType)
+			}
+			if tc.sender != "" {
+				req.Header.Set(serviceIDHeader, tc.sender)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			if rec.Code != tc.want {
+				t.Errorf("status = %d, want %d: %s", rec.Code, tc.want, rec.Body)
+			}
+		})
+	}
+
+	msg := ServiceMessage{ServiceID: "pb-peer", Version: cfg.Version, Timestamp: 1}
+	req := httptest.NewRequest(http.MethodPost, "/message", bytes.NewReader(marshalProto(t, protoServiceMessage(msg))))
+	req.Header.Set("Content-Type", semverxpb.ContentType)
+	req.Header.Set(serviceIDHeader, "pb-peer")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("protobuf status = %d: %s", rec.Code, rec.Body)
+	}
+}
+
+func TestRequiredHeadersDisabled(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.RequiredHeaders = nil
+	rec := httptest.NewRecorder()
+	NewServer(cfg, WithLogOutput(io.Discard)).Routes().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/message",
+		strings.NewReader(`{"service_id":"rust-service","version":"v1.stable.0.stable.0.stable","timestamp":1}`)))
+	if rec.Code != http.StatusOK {
+		t.Errorf("status = %d without required headers configured: %s", rec.Code, rec.Body)
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// HealthChecker is one readiness condition reported by /health/ready.
+type HealthChecker interface {
+	Name() string
+	Check(ctx context.Context) error
+}
+
+type healthCheckFunc struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+// NewHealthCheck adapts fn into a HealthChecker called name.
+func NewHealthCheck(name string, fn func(ctx context.Context) error) HealthChecker {
+	return healthCheckFunc{name, fn}
+}
+
+func (c healthCheckFunc) Name() string                    { return c.name }
+func (c healthCheckFunc) Check(ctx context.Context) error { return c.fn(ctx) }
+
+// peersCheck fails when no peer is registered once the startup grace
+// period has passed.
+func peersCheck(peers *PeerRegistry, started time.Time, grace time.Duration) HealthChecker {
+	return NewHealthCheck("peers", func(ctx context.Context) error {
+		if len(peers.List()) > 0 || peers.now().Sub(started) < grace {
+			return nil
+		}
+		return fmt.Errorf("no peers seen within %s of startup", grace)
+	})
+}
+
+// upstreamCheck fails unless GET url answers with a 2xx status.
+func upstreamCheck(client *http.Client, url string) HealthChecker {
+	return NewHealthCheck("upstream "+url, func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode/100 != 2 {
+			return fmt.Errorf("responded %s", resp.Status)
+		}
+		return nil
+	})
+}
+
+// AddHealthCheck registers c with the readiness probe. It must be called
+// before the server starts handling requests.
+func (s *Server) AddHealthCheck(c HealthChecker) {
+	s.checkers = append(s.checkers, c)
+}
+
+// CheckResult is the outcome of one HealthChecker.
+type CheckResult struct {
+	Name  string `json:"name"`
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+// HealthStatus is the /health and /health/ready response body.
+type HealthStatus struct {
+	ServiceID string        `json:"service_id"`
+	Healthy   bool          `json:"healthy"`
+	Version   string        `json:"version"`
+	Checks    []CheckResult `json:"checks"`
+}
+
+const healthCheckTimeout = 2 * time.Second
+
+// liveHandler reports that the process is up and serving.
+func (s *Server) liveHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"service_id": s.cfg.ServiceID,
+		"alive":      true,
+	})
+}
+
+// healthHandler is the readiness probe: it runs every HealthChecker and
+// answers 503 when any of them fails.
+func (s *Server) healthHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), healthCheckTimeout)
+	defer cancel()
+
+	healthy := true
+	checks := make([]CheckResult, len(s.checkers))
+	for i, c := range s.checkers {
+		checks[i] = CheckResult{Name: c.Name(), OK: true}
+		if err := c.Check(ctx); err != nil {
+			healthy = false
+			checks[i].OK, checks[i].Error = false, err.Error()
+		}
+	}
+	status := http.StatusOK
+	if !healthy {
+		status = http.StatusServiceUnavailable
+	}
+	body := HealthStatus{ServiceID: s.cfg.ServiceID, Healthy: healthy, Version: s.cfg.Version.String(), Checks: checks}
+	writeNegotiated(w, r, status, body)
+}
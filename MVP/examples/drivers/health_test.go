@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/obinexus/rust-semverx/MVP/examples/drivers/semverx"
+)
+
+type healthBody struct {
+	Healthy bool          `json:"healthy"`
+	Checks  []CheckResult `json:"checks"`
+}
+
+func getHealth(t *testing.T, h http.Handler, path string) (int, healthBody) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	var body healthBody
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	return rec.Code, body
+}
+
+func TestHealthReadyFailingCheck(t *testing.T) {
+	srv := newTestServer()
+	srv.AddHealthCheck(NewHealthCheck("ok", func(context.Context) error { return nil }))
+	srv.AddHealthCheck(NewHealthCheck("database", func(context.Context) error { return errors.New("connection refused") }))
+	h := srv.Routes()
+
+	for _, path := range []string{"/health", "/health/ready"} {
+		code, body := getHealth(t, h, path)
+		if code != http.StatusServiceUnavailable || body.Healthy {
+			t.Errorf("%s: status = %d, healthy = %v; want 503", path, code, body.Healthy)
+		}
+		if len(body.Checks) != 2 || !body.Checks[0].OK || body.Checks[1].OK || body.Checks[1].Error != "connection refused" {
+			t.Errorf("%s: checks = %+v", path, body.Checks)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health/live", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("/health/live status = %d while ready check fails", rec.Code)
+	}
+}
+
+func TestHealthReadyNoChecks(t *testing.T) {
+	code, body := getHealth(t, newTestServer().Routes(), "/health/ready")
+	if code != http.StatusOK || !body.Healthy {
+		t.Errorf("status = %d, body = %+v", code, body)
+	}
+}
+
+func TestPeersCheck(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	peers := NewPeerRegistry(0)
+	peers.now = func() time.Time { return now }
+	check := peersCheck(peers, now, 30*time.Second)
+
+	if err := check.Check(context.Background()); err != nil {
+		t.Errorf("within grace: %v", err)
+	}
+	now = now.Add(time.Minute)
+	if err := check.Check(context.Background()); err == nil {
+		t.Error("empty registry after grace passed the check")
+	}
+	peers.Register("rust-service", semverx.MustParseVersion("v1.stable.0.stable.0.stable"))
+	if err := check.Check(context.Background()); err != nil {
+		t.Errorf("with a peer: %v", err)
+	}
+}
+
+func TestUpstreamCheck(t *testing.T) {
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer up.Close()
+	defer down.Close()
+
+	cfg := DefaultConfig()
+	cfg.Upstreams = []string{up.URL}
+	if code, body := getHealth(t, NewServer(cfg, WithLogOutput(io.Discard)).Routes(), "/health/ready"); code != http.StatusOK {
+		t.Errorf("reachable upstream: status = %d, %+v", code, body)
+	}
+	cfg.Upstreams = []string{up.URL, down.URL}
+	if code, _ := getHealth(t, NewServer(cfg, WithLogOutput(io.Discard)).Routes(), "/health/ready"); code != http.StatusServiceUnavailable {
+		t.Errorf("failing upstream: status = %d, want 503", code)
+	}
+}
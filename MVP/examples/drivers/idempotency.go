@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	idempotencyKeyHeader = "Idempotency-Key"
+	// idempotencyPendingRetry is the Retry-After, in seconds, sent to a
+	// request whose key is still being processed.
+	idempotencyPendingRetry = "1"
+	// idempotentReplayedHeader marks a response served from the cache.
+	idempotentReplayedHeader = "Idempotent-Replayed"
+)
+
+// idempotencyCache remembers the response to each (sender, key) pair for a
+// TTL so retried requests are answered without being processed again.
+type idempotencyCache struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*idempotentResponse // sender|key -> response
+	expiry  expiryQueue                    // finished entries
+}
+
+// idempotentResponse is a cached response. It is pending, with done still
+// open, while the first request is being processed.
+type idempotentResponse struct {
+	digest  [sha256.Size]byte
+	done    chan struct{}
+	expires time.Time
+
+	status      int
+	contentType string
+	body        []byte
+}
+
+func newIdempotencyCache(ttl time.Duration) *idempotencyCache {
+	return &idempotencyCache{ttl: ttl, now: time.Now, entries: make(map[string]*idempotentResponse)}
+}
+
+// begin looks up key for a request with the given body digest. It returns
+// the entry and whether the caller owns it and must finish it; otherwise
+// the entry belongs to an earlier request with the same key.
+func (c *idempotencyCache) begin(key string, digest [sha256.Size]byte) (*idempotentResponse, bool) {
+	now := c.now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for {
+		k, ok := c.expiry.popExpired(now)
+		if !ok {
+			break
+		}
+		if e, ok := c.entries[k]; ok && !e.expires.IsZero() && now.After(e.expires) {
+			delete(c.entries, k)
+		}
+	}
+	if e, ok := c.entries[key]; ok {
+		return e, false
+	}
+	e := &idempotentResponse{digest: digest, done: make(chan struct{})}
+	c.entries[key] = e
+	return e, true
+}
+
+// finish completes e. Responses a client would retry are not cached, so
+// the retry is processed afresh.
+func (c *idempotencyCache) finish(key string, e *idempotentResponse, rec *responseCapture) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if retryableStatus(rec.status) {
+		delete(c.entries, key)
+	} else {
+		e.status, e.contentType, e.body = rec.status, rec.Header().Get("Content-Type"), rec.buf.Bytes()
+		e.expires = c.now().Add(c.ttl)
+		c.expiry.add(key, e.expires)
+	}
+	close(e.done)
+}
+
+// responseCapture passes a response through while keeping a copy of its
+// status and body.
+type responseCapture struct {
+	statusRecorder
+	buf bytes.Buffer
+}
+
+func (rc *responseCapture) Write(b []byte) (int, error) {
+	rc.buf.Write(b)
+	return rc.statusRecorder.Write(b)
+}
+
+// withIdempotency answers a message request carrying an Idempotency-Key
+// header with the response to the first request its sender made with that
+// key, if that was within the TTL. Keys are scoped per sender, taken from
+// the service_id of the message withMessage decoded. Reusing a key for a
+// different body is rejected with 422. A request whose key is still being
+// processed, typically a retry sent after the client gave up waiting, is
+// answered 503 with Retry-After so that the client tries again once the
+// first has finished. Responses the retry client would retry (5xx, 408,
+// 429) are not cached.
+func (s *Server) withIdempotency(next http.Handler) http.Handler {
+	if s.idempotency == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(idempotencyKeyHeader)
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		d := requestMessage(r)
+		if d.err != nil {
+			// The handler reports the error; nothing worth caching.
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		scoped := d.msg.ServiceID + "|" + key
+		e, owner := s.idempotency.begin(scoped, sha256.Sum256(d.body))
+		if !owner {
+			select {
+			case <-e.done:
+			default:
+				w.Header().Set("Retry-After", idempotencyPendingRetry)
+				writeError(w, http.StatusServiceUnavailable, "a request with this idempotency key is still being processed")
+				return
+			}
+			if e.digest != sha256.Sum256(d.body) {
+				writeError(w, http.StatusUnprocessableEntity, "idempotency key was already used for a different request")
+				return
+			}
+			if e.contentType != "" {
+				w.Header().Set("Content-Type", e.contentType)
+			}
+			w.Header().Set(idempotentReplayedHeader, "true")
+			w.WriteHeader(e.status)
+			w.Write(e.body)
+			return
+		}
+
+		rec := &responseCapture{statusRecorder: statusRecorder{ResponseWriter: w}}
+		defer func() {
+			if rec.status == 0 {
+				rec.status = http.StatusOK
+			}
+			s.idempotency.finish(scoped, e, rec)
+		}()
+		next.ServeHTTP(rec, r)
+	})
+}
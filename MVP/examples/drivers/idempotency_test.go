@@ -0,0 +1,136 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// idempotentServer wraps withIdempotency around a handler that answers
+// status and counts its calls, and returns the cache's clock.
+func idempotentServer(t *testing.T, status int) (http.Handler, *time.Time, *int) {
+	t.Helper()
+	now := time.Unix(1700000000, 0)
+	srv := NewServer(DefaultConfig(), WithLogOutput(io.Discard))
+	srv.idempotency.now = func() time.Time { return now }
+	calls := 0
+	h := srv.withMessage(srv.withIdempotency(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(status)
+		w.Write([]byte("processed"))
+	})))
+	return h, &now, &calls
+}
+
+func postIdempotent(h http.Handler, key, sender string) *httptest.ResponseRecorder {
+	body := `{"service_id":"` + sender + `","version":"v1.stable.0.stable.0.stable","timestamp":1}`
+	req := httptest.NewRequest(http.MethodPost, "/message", strings.NewReader(body))
+	if key != "" {
+		req.Header.Set(idempotencyKeyHeader, key)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestIdempotencyReplaysCachedResponse(t *testing.T) {
+	h, _, calls := idempotentServer(t, http.StatusAccepted)
+	first := postIdempotent(h, "k1", "rust-service")
+	second := postIdempotent(h, "k1", "rust-service")
+	if *calls != 1 {
+		t.Errorf("handler ran %d times, want 1", *calls)
+	}
+	if second.Code != http.StatusAccepted || second.Body.String() != "processed" || second.Header().Get("Content-Type") != "text/plain" {
+		t.Errorf("replayed response = %d %q %v", second.Code, second.Body, second.Header())
+	}
+	if first.Header().Get(idempotentReplayedHeader) != "" || second.Header().Get(idempotentReplayedHeader) != "true" {
+		t.Errorf("%s = %q then %q", idempotentReplayedHeader, first.Header().Get(idempotentReplayedHeader), second.Header().Get(idempotentReplayedHeader))
+	}
+
+	// The key is scoped to its sender, and requests without one always run.
+	postIdempotent(h, "k1", "python-service")
+	postIdempotent(h, "", "rust-service")
+	postIdempotent(h, "", "rust-service")
+	if *calls != 4 {
+		t.Errorf("handler ran %d times, want 4", *calls)
+	}
+}
+
+func TestIdempotencyExpiry(t *testing.T) {
+	h, now, calls := idempotentServer(t, http.StatusOK)
+	postIdempotent(h, "k1", "rust-service")
+	*now = now.Add(defaultIdempotencyTTL - time.Second)
+	postIdempotent(h, "k1", "rust-service")
+	if *calls != 1 {
+		t.Fatalf("handler ran %d times within the TTL, want 1", *calls)
+	}
+	*now = now.Add(2 * time.Second)
+	if rec := postIdempotent(h, "k1", "rust-service"); rec.Header().Get(idempotentReplayedHeader) != "" || *calls != 2 {
+		t.Errorf("expired key replayed: %d calls, header %v", *calls, rec.Header())
+	}
+}
+
+func TestIdempotencyDoesNotCacheRetryable(t *testing.T) {
+	h, _, calls := idempotentServer(t, http.StatusServiceUnavailable)
+	postIdempotent(h, "k1", "rust-service")
+	postIdempotent(h, "k1", "rust-service")
+	if *calls != 2 {
+		t.Errorf("handler ran %d times, want a retryable response to be processed again", *calls)
+	}
+}
+
+func TestIdempotencyPending(t *testing.T) {
+	srv := NewServer(DefaultConfig(), WithLogOutput(io.Discard))
+	started, release := make(chan struct{}), make(chan struct{})
+	h := srv.withMessage(srv.withIdempotency(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	})))
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- postIdempotent(h, "k1", "rust-service") }()
+	<-started
+	rec := postIdempotent(h, "k1", "rust-service")
+	if rec.Code != http.StatusServiceUnavailable || rec.Header().Get("Retry-After") == "" {
+		t.Errorf("pending key: status = %d, Retry-After = %q, want 503 with Retry-After", rec.Code, rec.Header().Get("Retry-After"))
+	}
+	close(release)
+	if first := <-done; first.Code != http.StatusOK {
+		t.Errorf("first request: status = %d", first.Code)
+	}
+	if rec := postIdempotent(h, "k1", "rust-service"); rec.Header().Get(idempotentReplayedHeader) != "true" {
+		t.Errorf("finished key not replayed: %d %v", rec.Code, rec.Header())
+	}
+}
+
+func TestIdempotencyKeyReuse(t *testing.T) {
+	h, _, _ := idempotentServer(t, http.StatusOK)
+	postIdempotent(h, "k1", "rust-service")
+	req := httptest.NewRequest(http.MethodPost, "/message", strings.NewReader(`{"service_id":"rust-service","version":"v1.stable.0.stable.0.stable","timestamp":2}`))
+	req.Header.Set(idempotencyKeyHeader, "k1")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("status = %d, want 422 for a reused key", rec.Code)
+	}
+}
+
+func TestIdempotencyBeforeReplayGuard(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ReplayWindow = time.Minute
+	h := NewServer(cfg, WithLogOutput(io.Discard)).Routes()
+	body := `{"service_id":"rust-service","version":"v1.stable.0.stable.0.stable","timestamp":` + strconv.FormatInt(time.Now().Unix(), 10) + `}`
+	for i := 0; i < 2; i++ {
+		req := newMessageRequest("/message", body)
+		req.Header.Set(idempotencyKeyHeader, "retry-1")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("attempt %d: status %d: %s", i+1, rec.Code, rec.Body)
+		}
+	}
+}
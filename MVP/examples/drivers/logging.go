@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+const requestIDHeader = "X-Request-ID"
+
+type requestLogKey struct{}
+
+// requestLog carries per-request fields that handlers fill in for the
+// access log entry.
+type requestLog struct {
+	sender string
+}
+
+// setSender records the sending service ID for the access log and the
+// trace span of r.
+func setSender(r *http.Request, id string) {
+	trace.SpanFromContext(r.Context()).SetAttributes(attrSender.String(id))
+	if rl, ok := r.Context().Value(requestLogKey{}).(*requestLog); ok {
+		rl.sender = id
+	}
+}
+
+// statusRecorder captures the status code written by a handler.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (sr *statusRecorder) WriteHeader(code int) {
+	if sr.status == 0 {
+		sr.status = code
+	}
+	sr.ResponseWriter.WriteHeader(code)
+}
+
+func (sr *statusRecorder) Write(b []byte) (int, error) {
+	if sr.status == 0 {
+		sr.status = http.StatusOK
+	}
+	return sr.ResponseWriter.Write(b)
+}
+
+func (sr *statusRecorder) Unwrap() http.ResponseWriter {
+	return sr.ResponseWriter
+}
+
+// Hijack hands the connection to the /stream WebSocket upgrade, which
+// answers 101 on it directly.
+func (sr *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	c, brw, err := http.NewResponseController(sr.ResponseWriter).Hijack()
+	if err == nil && sr.status == 0 {
+		sr.status = http.StatusSwitchingProtocols
+	}
+	return c, brw, err
+}
+
+// logRequests emits one structured entry per request and propagates or
+// generates an X-Request-ID, which is echoed in the response. Health checks
+// are logged at debug level to keep probes out of the default output.
+func (s *Server) logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		id := r.Header.Get(requestIDHeader)
+		if id == "" {
+			id = newRequestID()
+		}
+		w.Header().Set(requestIDHeader, id)
+
+		rl := &requestLog{sender: r.Header.Get(serviceIDHeader)}
+		r = r.WithContext(context.WithValue(r.Context(), requestLogKey{}, rl))
+		rec := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+		if rec.status == 0 {
+			rec.status = http.StatusOK
+		}
+
+		level := slog.LevelInfo
+		if strings.HasPrefix(r.URL.Path, "/health") {
+			level = slog.LevelDebug
+		}
+		s.log.LogAttrs(r.Context(), level, "request",
+			slog.String("request_id", id),
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", rec.status),
+			slog.Duration("duration", time.Since(start)),
+			slog.String("sender", rl.sender),
+		)
+	})
+}
+
+func newRequestID() string {
+	var b [8]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
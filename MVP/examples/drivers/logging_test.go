@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func captureLogs(srv *Server, level slog.Level) *bytes.Buffer {
+	var buf bytes.Buffer
+	srv.log = slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: level}))
+	return &buf
+}
+
+func logEntries(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+	var out []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var e map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			t.Fatalf("log line %q: %v", line, err)
+		}
+		if e["msg"] == "request" {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+func TestRequestLogging(t *testing.T) {
+	srv := newTestServer()
+	buf := captureLogs(srv, slog.LevelInfo)
+	h := srv.Routes()
+
+	req := newMessageRequest("/message", `{"service_id":"rust-service","version":"v1.stable.0.stable.0.stable","timestamp":1}`)
+	req.Header.Set(requestIDHeader, "abc-123")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if got := rec.Header().Get(requestIDHeader); got != "abc-123" {
+		t.Errorf("propagated request ID = %q", got)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, newMessageRequest("/message", `{}`))
+	generated := rec.Header().Get(requestIDHeader)
+	if generated == "" {
+		t.Error("no request ID generated")
+	}
+
+	// Health checks stay below the info level.
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/health", nil))
+
+	entries := logEntries(t, buf)
+	if len(entries) != 2 {
+		t.Fatalf("got %d request entries, want 2: %s", len(entries), buf)
+	}
+	first := entries[0]
+	for k, want := range map[string]interface{}{
+		"request_id": "abc-123", "method": "POST", "path": "/message",
+		"status": 200.0, "sender": "rust-service",
+	} {
+		if first[k] != want {
+			t.Errorf("entry[%s] = %v, want %v", k, first[k], want)
+		}
+	}
+	if _, ok := first["duration"]; !ok {
+		t.Error("entry has no duration")
+	}
+	if entries[1]["status"] != 400.0 || entries[1]["request_id"] != generated {
+		t.Errorf("second entry = %v", entries[1])
+	}
+}
+
+func TestHealthLoggedAtDebug(t *testing.T) {
+	srv := newTestServer()
+	buf := captureLogs(srv, slog.LevelDebug)
+	srv.Routes().ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/health", nil))
+	entries := logEntries(t, buf)
+	if len(entries) != 1 || entries[0]["level"] != "DEBUG" {
+		t.Errorf("health entries = %v", entries)
+	}
+}
+
+func TestWithLogOutput(t *testing.T) {
+	var buf bytes.Buffer
+	srv := NewServer(DefaultConfig(), WithLogOutput(&buf))
+	srv.Routes().ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/version", nil))
+	entries := logEntries(t, &buf)
+	if len(entries) != 1 || entries[0]["service_id"] != DefaultConfig().ServiceID {
+		t.Errorf("entries = %v", entries)
+	}
+}
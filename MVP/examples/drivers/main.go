@@ -1,39 +1,99 @@
 package main
 
 import (
-    "encoding/json"
-    "fmt"
-    "net/http"
-    "log"
+	"context"
+	"encoding/json"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/obinexus/rust-semverx/MVP/examples/drivers/semverx"
 )
 
 type ServiceMessage struct {
-    ServiceID string          `json:"service_id"`
-    Version   string          `json:"version"`
-    Payload   json.RawMessage `json:"payload"`
-    Timestamp int64           `json:"timestamp"`
+	ServiceID string          `json:"service_id"`
+	Version   semverx.Version `json:"version"`
+	Payload   json.RawMessage `json:"payload"`
+	Timestamp int64           `json:"timestamp"`
+	Signature string          `json:"signature,omitempty"`
+	// MessageType names the payload schema; see PayloadCodec.
+	MessageType string `json:"message_type,omitempty"`
+	// Visited lists the services that relayed this message, in order.
+	Visited []string `json:"visited,omitempty"`
+	// RawVersion is the version string exactly as it was decoded, which
+	// may differ from Version.String(), e.g. by lacking the 'v' prefix.
+	// Signatures cover it, and it is encoded again in place of Version.
+	// Clear it when changing Version.
+	RawVersion string `json:"-"`
 }
 
-func main() {
-    http.HandleFunc("/health", healthHandler)
-    http.HandleFunc("/message", messageHandler)
-    
-    fmt.Println("[go-service] P2P Service listening on :3002")
-    log.Fatal(http.ListenAndServe(":3002", nil))
+// wireVersion is the version string as the sender wrote it.
+func (m ServiceMessage) wireVersion() string {
+	if m.RawVersion != "" {
+		return m.RawVersion
+	}
+	return m.Version.String()
+}
+
+// MarshalJSON encodes RawVersion, when set, as the version. The fields
+// below must be kept in step with ServiceMessage.
+func (m ServiceMessage) MarshalJSON() ([]byte, error) {
+	type plain ServiceMessage
+	if m.RawVersion == "" {
+		return json.Marshal(plain(m))
+	}
+	return json.Marshal(struct {
+		ServiceID   string          `json:"service_id"`
+		Version     string          `json:"version"`
+		Payload     json.RawMessage `json:"payload"`
+		Timestamp   int64           `json:"timestamp"`
+		Signature   string          `json:"signature,omitempty"`
+		MessageType string          `json:"message_type,omitempty"`
+		Visited     []string        `json:"visited,omitempty"`
+	}{m.ServiceID, m.RawVersion, m.Payload, m.Timestamp, m.Signature, m.MessageType, m.Visited})
 }
 
-func healthHandler(w http.ResponseWriter, r *http.Request) {
-    response := map[string]interface{}{
-        "service_id": "go-service",
-        "healthy":    true,
-        "version":    "v1.stable.0.stable.0.stable",
-    }
-    json.NewEncoder(w).Encode(response)
+// UnmarshalJSON decodes the version with semverx.ParseLenient, so a message
+// from a peer on a channel this node does not know is still read and then
+// refused as incompatible rather than as malformed.
+func (m *ServiceMessage) UnmarshalJSON(b []byte) error {
+	type plain ServiceMessage
+	var wire struct {
+		plain
+		Version *string `json:"version"`
+	}
+	if err := json.Unmarshal(b, &wire); err != nil {
+		return err
+	}
+	*m = ServiceMessage(wire.plain)
+	if wire.Version != nil {
+		v, err := semverx.ParseLenient(*wire.Version)
+		if err != nil {
+			return err
+		}
+		m.Version, m.RawVersion = v, *wire.Version
+	}
+	return nil
 }
 
-func messageHandler(w http.ResponseWriter, r *http.Request) {
-    var msg ServiceMessage
-    json.NewDecoder(r.Body).Decode(&msg)
-    fmt.Printf("Received message from %s\n", msg.ServiceID)
-    w.Write([]byte("Message received"))
+func main() {
+	cfg, err := loadConfig(os.Args[1:], os.Getenv, os.Stderr)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	shutdownTracing, err := setupTracing(ctx, cfg, os.Getenv)
+	if err != nil {
+		log.Fatal(err)
+	}
+	err = NewServer(cfg).ListenAndServe(ctx)
+	if terr := shutdownTracing(context.Background()); terr != nil {
+		log.Print("tracing shutdown: ", terr)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
 }
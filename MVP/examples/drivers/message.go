@@ -0,0 +1,261 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/obinexus/rust-semverx/MVP/examples/drivers/semverx"
+)
+
+// messageError is a rejected message: the HTTP status to answer with and
+// the JSON body fields describing why.
+type messageError struct {
+	status int
+	fields map[string]string
+}
+
+func rejectMessage(status int, reason string) *messageError {
+	return &messageError{status: status, fields: map[string]string{"error": reason}}
+}
+
+// rejectInvalid is rejectMessage for input that failed to parse. When err
+// is one of the semverx parse errors its kind is reported as "code".
+func rejectInvalid(status int, reason string, err error) *messageError {
+	merr := rejectMessage(status, reason)
+	if code := versionErrorCode(err); code != "" {
+		merr.fields["code"] = code
+	}
+	return merr
+}
+
+// errorCode returns the "code" an error body reports for err: the one set
+// on a messageError, or else the kind of a semverx parse error.
+func errorCode(err error) string {
+	var merr *messageError
+	if errors.As(err, &merr) {
+		return merr.fields["code"]
+	}
+	return versionErrorCode(err)
+}
+
+// versionErrorCodes names the semverx parse errors in JSON error bodies.
+var versionErrorCodes = []struct {
+	err  error
+	code string
+}{
+	{semverx.ErrEmptyVersion, "empty_version"},
+	{semverx.ErrMissingPrefix, "missing_prefix"},
+	{semverx.ErrUnknownChannel, "unknown_channel"},
+	{semverx.ErrBadComponent, "bad_component"},
+	{semverx.ErrMalformed, "malformed_version"},
+	{semverx.ErrEmptyConstraint, "empty_constraint"},
+	{semverx.ErrUnsatisfiable, "unsatisfiable_constraint"},
+}
+
+// versionErrorCode returns the code of the semverx parse error in err's
+// chain, or "" when there is none.
+func versionErrorCode(err error) string {
+	for _, c := range versionErrorCodes {
+		if errors.Is(err, c.err) {
+			return c.code
+		}
+	}
+	return ""
+}
+
+func (e *messageError) Error() string {
+	if r := e.fields["reason"]; r != "" {
+		return e.fields["error"] + ": " + r
+	}
+	return e.fields["error"]
+}
+
+// messageHandler accepts a single message, encoded as JSON or, with
+// Content-Type application/x-protobuf, as protobuf. It runs behind
+// withMessage, which decoded the body.
+func (s *Server) messageHandler(w http.ResponseWriter, r *http.Request) {
+	d := requestMessage(r)
+	if d.err != nil {
+		s.metrics.MessageRejected("malformed")
+		merr := rejectInvalid(http.StatusBadRequest, fmt.Sprintf("invalid message body: %v", d.err), d.err)
+		writeJSON(w, merr.status, merr.fields)
+		return
+	}
+	msg := d.msg
+	setSender(r, msg.ServiceID)
+	if merr := s.acceptMessage(r.Context(), msg); merr != nil {
+		writeJSON(w, merr.status, merr.fields)
+		return
+	}
+	if r.URL.Query().Get("forward") == "true" {
+		for _, res := range s.forwarder.Forward(r.Context(), msg) {
+			if res.Err != nil {
+				s.log.Warn("forward failed", "peer", res.ServiceID, "err", res.Err)
+			}
+		}
+	}
+	w.Write([]byte("Message received"))
+}
+
+// acceptMessage authenticates, validates and compatibility-checks msg and
+// dispatches its payload. Only when the payload handler succeeds is the
+// sender registered, the message counted as received and published on the
+// bus.
+func (s *Server) acceptMessage(ctx context.Context, msg ServiceMessage) *messageError {
+	v, merr := s.checkMessage(msg)
+	if !v.IsZero() {
+		trace.SpanFromContext(ctx).SetAttributes(attrVersion.String(v.String()), attrChannel.String(v.LowestChannel().String()))
+	}
+	if merr != nil {
+		// A peer on a version this node cannot fully read is still listed,
+		// flagged degraded, though none of its messages are accepted.
+		if v.Degraded && merr.status == http.StatusConflict {
+			s.peers.Register(msg.ServiceID, v)
+		}
+		s.metrics.MessageRejected(rejectReason(merr.status))
+		return merr
+	}
+
+	if err := s.payloads.Dispatch(ctx, msg); err != nil {
+		var de *PayloadDecodeError
+		if errors.As(err, &de) {
+			s.metrics.MessageRejected("invalid_payload")
+			return rejectMessage(http.StatusBadRequest, err.Error())
+		}
+		s.metrics.MessageRejected("handler_failed")
+		s.log.Error("payload handler failed", "sender", msg.ServiceID, "message_type", msg.MessageType, "err", err)
+		return rejectMessage(http.StatusInternalServerError, "payload handler failed")
+	}
+	s.peers.Register(msg.ServiceID, v)
+	s.metrics.MessageReceived(v.LowestChannel())
+	s.log.Debug("message accepted", "sender", msg.ServiceID, "version", v.String())
+	s.bus.Publish(msg)
+	return nil
+}
+
+func (s *Server) checkMessage(msg ServiceMessage) (semverx.Version, *messageError) {
+	if len(s.cfg.Secret) > 0 {
+		if err := VerifyMessage(msg, s.cfg.Secret); err != nil {
+			return semverx.Version{}, rejectMessage(http.StatusUnauthorized, err.Error())
+		}
+	}
+	if merr := s.checkACL(msg.ServiceID); merr != nil {
+		return semverx.Version{}, merr
+	}
+	v, err := validateMessage(msg)
+	if err != nil {
+		return semverx.Version{}, rejectMessage(http.StatusBadRequest, err.Error())
+	}
+	if merr := s.checkStability(v); merr != nil {
+		return v, merr
+	}
+	if report := semverx.CheckCompatibility(s.cfg.Version, v); !report.Compatible {
+		return v, &messageError{status: http.StatusConflict, fields: map[string]string{
+			"error":          "incompatible version",
+			"code":           "incompatible_version",
+			"reason":         report.Reason(),
+			"local_version":  s.cfg.Version.String(),
+			"remote_version": v.String(),
+		}}
+	}
+	return v, nil
+}
+
+// checkStability applies the configured stability floor to v.
+func (s *Server) checkStability(v semverx.Version) *messageError {
+	var sv *semverx.StabilityViolation
+	if !errors.As(s.cfg.Stability.Check(v), &sv) {
+		return nil
+	}
+	return &messageError{status: http.StatusForbidden, fields: map[string]string{
+		"error":       "stability policy violation",
+		"reason":      sv.Error(),
+		"component":   sv.Component,
+		"channel":     sv.Channel.String(),
+		"min_channel": sv.MinChannel.String(),
+	}}
+}
+
+// validateMessage checks the envelope fields and returns the parsed sender
+// version.
+func validateMessage(msg ServiceMessage) (semverx.Version, error) {
+	if msg.ServiceID == "" {
+		return semverx.Version{}, errors.New("service_id is required")
+	}
+	if msg.Timestamp <= 0 {
+		return semverx.Version{}, fmt.Errorf("timestamp must be positive, got %d", msg.Timestamp)
+	}
+	if msg.Version.IsZero() {
+		return semverx.Version{}, errors.New("version is required")
+	}
+	return msg.Version, nil
+}
+
+// BatchResult is one entry of the /messages/batch response body.
+type BatchResult struct {
+	Index  int    `json:"index"`
+	Status string `json:"status"`
+	Reason string `json:"reason,omitempty"`
+	// Code names the kind of a version parse failure; see rejectInvalid.
+	Code string `json:"code,omitempty"`
+}
+
+// batchHandler accepts a JSON array of messages and processes them in
+// order. A failing item is reported in its result and does not stop the
+// rest of the batch. Replay protection, when enabled, applies per item.
+func (s *Server) batchHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeError(w, http.StatusMethodNotAllowed, "batch requires POST")
+		return
+	}
+	var items []json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&items); err != nil {
+		writeBodyError(w, "invalid batch body", err)
+		return
+	}
+	if len(items) > s.cfg.MaxBatchSize {
+		writeError(w, http.StatusRequestEntityTooLarge,
+			fmt.Sprintf("batch of %d messages exceeds the limit of %d", len(items), s.cfg.MaxBatchSize))
+		return
+	}
+
+	results := make([]BatchResult, len(items))
+	for i, raw := range items {
+		results[i] = BatchResult{Index: i, Status: "ok"}
+		if err := s.acceptBatchItem(r.Context(), raw); err != nil {
+			results[i].Status = "error"
+			results[i].Reason = err.Error()
+			results[i].Code = errorCode(err)
+		}
+	}
+	writeJSON(w, http.StatusOK, results)
+}
+
+func (s *Server) acceptBatchItem(ctx context.Context, raw json.RawMessage) error {
+	var msg ServiceMessage
+	if err := json.Unmarshal(raw, &msg); err != nil {
+		s.metrics.MessageRejected("malformed")
+		return fmt.Errorf("invalid message: %w", err)
+	}
+	var replayKey string
+	if s.replay != nil {
+		var err error
+		if replayKey, err = s.replay.reserve(msg); err != nil {
+			s.metrics.MessageRejected("replay")
+			return replayRejection(err)
+		}
+	}
+	if merr := s.acceptMessage(ctx, msg); merr != nil {
+		if s.replay != nil {
+			s.replay.release(replayKey)
+		}
+		return merr
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func postBatch(t *testing.T, srv *Server, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	srv.Routes().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/messages/batch", strings.NewReader(body)))
+	return rec
+}
+
+func TestBatchHandler(t *testing.T) {
+	srv := newTestServer()
+	rec := postBatch(t, srv, `[
+		{"service_id":"rust-service","version":"v1.stable.0.stable.0.stable","timestamp":1},
+		{"service_id":"rust-service","version":"bad version","timestamp":2},
+		"not an object",
+		{"service_id":"python-service","version":"v2.stable.0.stable.0.stable","timestamp":3},
+		{"service_id":"python-service","version":"v1.stable.1.stable.0.stable","timestamp":4}
+	]`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", rec.Code, rec.Body)
+	}
+	var results []BatchResult
+	if err := json.NewDecoder(rec.Body).Decode(&results); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"ok", "error", "error", "error", "ok"}
+	if len(results) != len(want) {
+		t.Fatalf("got %d results, want %d", len(results), len(want))
+	}
+	for i, r := range results {
+		if r.Index != i || r.Status != want[i] {
+			t.Errorf("result %d = %+v, want status %s", i, r, want[i])
+		}
+		if (r.Status == "error") != (r.Reason != "") {
+			t.Errorf("result %d: reason %q inconsistent with status", i, r.Reason)
+		}
+	}
+	if len(srv.peers.List()) != 2 {
+		t.Errorf("peers = %+v, want both accepted senders", srv.peers.List())
+	}
+}
+
+func TestBatchHandlerLimits(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.MaxBatchSize = 2
+	srv := NewServer(cfg, WithLogOutput(io.Discard))
+	item := `{"service_id":"a","version":"v1.stable.0.stable.0.stable","timestamp":1}`
+
+	if rec := postBatch(t, srv, fmt.Sprintf("[%s,%s]", item, item)); rec.Code != http.StatusOK {
+		t.Errorf("batch at limit: status = %d", rec.Code)
+	}
+	if rec := postBatch(t, srv, fmt.Sprintf("[%s,%s,%s]", item, item, item)); rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("batch over limit: status = %d, want 413", rec.Code)
+	}
+	if rec := postBatch(t, srv, `{"service_id":"a"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("non-array body: status = %d, want 400", rec.Code)
+	}
+	if rec := postBatch(t, srv, `[]`); rec.Code != http.StatusOK {
+		t.Errorf("empty batch: status = %d", rec.Code)
+	}
+}
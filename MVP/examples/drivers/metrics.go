@@ -0,0 +1,97 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/obinexus/rust-semverx/MVP/examples/drivers/semverx"
+)
+
+// Metrics receives the service's operational events. It is an interface so
+// tests can count events without scraping /metrics.
+type Metrics interface {
+	// MessageReceived counts an accepted message; channel is the least
+	// stable channel of the sender's version.
+	MessageReceived(channel semverx.Channel)
+	// MessageRejected counts a refused message by reason, e.g. "invalid".
+	MessageRejected(reason string)
+	// Negotiation counts a /negotiate call by result, e.g. "ok".
+	Negotiation(result string)
+	// ObserveLatency records how long a handler took.
+	ObserveLatency(route string, d time.Duration)
+}
+
+// promMetrics implements Metrics on a dedicated Prometheus registry.
+type promMetrics struct {
+	registry     *prometheus.Registry
+	received     *prometheus.CounterVec
+	rejected     *prometheus.CounterVec
+	negotiations *prometheus.CounterVec
+	latency      *prometheus.HistogramVec
+}
+
+func newPromMetrics() *promMetrics {
+	m := &promMetrics{
+		registry: prometheus.NewRegistry(),
+		received: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "semverx_messages_received_total",
+			Help: "Messages accepted, by the sender's least stable channel.",
+		}, []string{"channel"}),
+		rejected: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "semverx_messages_rejected_total",
+			Help: "Messages rejected, by reason.",
+		}, []string{"reason"}),
+		negotiations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "semverx_negotiations_total",
+			Help: "Version negotiations, by result.",
+		}, []string{"result"}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "semverx_handler_duration_seconds",
+			Help:    "Handler latency, by route.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"route"}),
+	}
+	m.registry.MustRegister(m.received, m.rejected, m.negotiations, m.latency)
+	return m
+}
+
+func (m *promMetrics) MessageReceived(c semverx.Channel) {
+	m.received.WithLabelValues(c.String()).Inc()
+}
+func (m *promMetrics) MessageRejected(reason string) { m.rejected.WithLabelValues(reason).Inc() }
+func (m *promMetrics) Negotiation(result string)     { m.negotiations.WithLabelValues(result).Inc() }
+
+func (m *promMetrics) ObserveLatency(route string, d time.Duration) {
+	m.latency.WithLabelValues(route).Observe(d.Seconds())
+}
+
+func (m *promMetrics) handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// instrument records the latency of h under route.
+func (s *Server) instrument(route string, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		h.ServeHTTP(w, r)
+		s.metrics.ObserveLatency(route, time.Since(start))
+	})
+}
+
+// rejectReason maps a message rejection status to its metric label.
+func rejectReason(status int) string {
+	switch status {
+	case http.StatusUnauthorized:
+		return "unauthorized"
+	case http.StatusConflict:
+		return "incompatible"
+	case http.StatusForbidden:
+		return "policy"
+	case http.StatusBadRequest:
+		return "invalid"
+	}
+	return "other"
+}
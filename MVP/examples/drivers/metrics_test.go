@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/obinexus/rust-semverx/MVP/examples/drivers/semverx"
+)
+
+type fakeMetrics struct {
+	mu           sync.Mutex
+	received     map[string]int
+	rejected     map[string]int
+	negotiations map[string]int
+	routes       map[string]int
+}
+
+func newFakeMetrics() *fakeMetrics {
+	return &fakeMetrics{
+		received:     map[string]int{},
+		rejected:     map[string]int{},
+		negotiations: map[string]int{},
+		routes:       map[string]int{},
+	}
+}
+
+func (m *fakeMetrics) MessageReceived(c semverx.Channel) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.received[c.String()]++
+}
+
+func (m *fakeMetrics) MessageRejected(reason string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.rejected[reason]++
+}
+
+func (m *fakeMetrics) Negotiation(result string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.negotiations[result]++
+}
+
+func (m *fakeMetrics) ObserveLatency(route string, d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.routes[route]++
+}
+
+func TestMetricsEvents(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Version = semverx.MustParseVersion("v1.stable.0.beta.0.stable")
+	srv := NewServer(cfg, WithLogOutput(io.Discard))
+	m := newFakeMetrics()
+	srv.metrics = m
+	h := srv.Routes()
+
+	post := func(path, body string) {
+		req := newMessageRequest(path, body)
+		req.Header.Set(serviceIDHeader, "a")
+		h.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	post("/message", `{"service_id":"a","version":"v1.stable.0.beta.0.stable","timestamp":1}`)
+	post("/message", `{"service_id":"a","version":"v1.stable.0.rc.0.stable","timestamp":1}`)
+	post("/message", `{"service_id":"a","version":"v2.stable.0.stable.0.stable","timestamp":1}`)
+	post("/message", `{"service_id":"","version":"v1.stable.0.stable.0.stable","timestamp":1}`)
+	post("/message", `not json`)
+	post("/negotiate", `{"service_id":"a","constraint":"^v1.stable.0.beta.0.stable"}`)
+	post("/negotiate", `{"service_id":"a","constraint":"^v9.stable.0.stable.0.stable"}`)
+
+	if m.received["beta"] != 1 || m.received["rc"] != 1 {
+		t.Errorf("received = %v", m.received)
+	}
+	for reason, n := range map[string]int{"incompatible": 1, "invalid": 1, "malformed": 1} {
+		if m.rejected[reason] != n {
+			t.Errorf("rejected[%s] = %d, want %d (all: %v)", reason, m.rejected[reason], n, m.rejected)
+		}
+	}
+	if m.negotiations["ok"] != 1 || m.negotiations["no_match"] != 1 {
+		t.Errorf("negotiations = %v", m.negotiations)
+	}
+	if m.routes["/message"] != 5 || m.routes["/negotiate"] != 2 {
+		t.Errorf("latency observations = %v", m.routes)
+	}
+}
+
+func TestPayloadFailureNotCounted(t *testing.T) {
+	srv := newTestServer()
+	m := newFakeMetrics()
+	srv.metrics = m
+	RegisterPayloadType(srv.payloads, "order", func(ctx context.Context, o struct{ ID int }) error {
+		if o.ID == 0 {
+			return errors.New("no such order")
+		}
+		return nil
+	})
+	h := srv.Routes()
+	post := func(body string) int {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, newMessageRequest("/message", body))
+		return rec.Code
+	}
+
+	if code := post(`{"service_id":"a","version":"v1.stable.0.stable.0.stable","timestamp":1,"message_type":"order","payload":{"ID":"x"}}`); code != http.StatusBadRequest {
+		t.Errorf("undecodable payload: status = %d, want 400", code)
+	}
+	if code := post(`{"service_id":"b","version":"v1.stable.0.stable.0.stable","timestamp":1,"message_type":"order","payload":{"ID":0}}`); code != http.StatusInternalServerError {
+		t.Errorf("failing handler: status = %d, want 500", code)
+	}
+	if len(m.received) != 0 || m.rejected["invalid_payload"] != 1 || m.rejected["handler_failed"] != 1 {
+		t.Errorf("received = %v, rejected = %v", m.received, m.rejected)
+	}
+	if peers := srv.peers.List(); len(peers) != 0 {
+		t.Errorf("failed messages registered %+v", peers)
+	}
+}
+
+func TestMetricsEndpoint(t *testing.T) {
+	ts := httptest.NewServer(newTestServer().Routes())
+	defer ts.Close()
+
+	resp, err := postServiceMessage(ts.URL+"/message", `{"service_id":"a","version":"v1.stable.0.stable.0.stable","timestamp":1}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	resp, err = http.Get(ts.URL + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	b, _ := io.ReadAll(resp.Body)
+	for _, want := range []string{
+		`semverx_messages_received_total{channel="stable"} 1`,
+		`semverx_handler_duration_seconds_count{route="/message"} 1`,
+	} {
+		if !strings.Contains(string(b), want) {
+			t.Errorf("/metrics missing %q", want)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/obinexus/rust-semverx/MVP/examples/drivers/semverx"
+)
+
+func newNegotiateServer() *Server {
+	cfg := DefaultConfig()
+	cfg.Supported = []semverx.Version{
+		semverx.MustParseVersion("v1.stable.0.stable.0.stable"),
+		semverx.MustParseVersion("v1.stable.2.stable.1.stable"),
+		semverx.MustParseVersion("v1.stable.3.beta.0.stable"),
+		semverx.MustParseVersion("v2.stable.0.stable.0.stable"),
+	}
+	return NewServer(cfg, WithLogOutput(io.Discard))
+}
+
+func negotiate(t *testing.T, srv *Server, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	srv.negotiateHandler(rec, httptest.NewRequest(http.MethodPost, "/negotiate", strings.NewReader(body)))
+	return rec
+}
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		constraint string
+		want       string
+	}{
+		{"^v1.stable.0.stable.0.stable", "v1.stable.2.stable.1.stable"},
+		{">=v1.stable.0.stable.0.stable, <v2.stable.0.stable.0.stable", "v1.stable.3.beta.0.stable"},
+		{">=v1.stable.0.stable.0.stable", "v2.stable.0.stable.0.stable"},
+		{"~v1.stable.0.stable.0.stable", "v1.stable.0.stable.0.stable"},
+	}
+	for _, tt := range tests {
+		srv := newNegotiateServer()
+		srv.peers.Register("rust-service", semverx.MustParseVersion("v1.stable.0.stable.0.stable"))
+		rec := negotiate(t, srv, `{"service_id":"rust-service","constraint":"`+tt.constraint+`"}`)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d, body = %s", tt.constraint, rec.Code, rec.Body)
+		}
+		var resp NegotiateResponse
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		if resp.Version != tt.want {
+			t.Errorf("%s: negotiated %s, want %s", tt.constraint, resp.Version, tt.want)
+		}
+		p, ok := srv.peers.Lookup("rust-service")
+		if !ok || p.Negotiated == nil || p.Negotiated.String() != tt.want {
+			t.Errorf("%s: registry entry = %+v, %v", tt.constraint, p, ok)
+		}
+	}
+}
+
+func TestNegotiateNoOverlap(t *testing.T) {
+	srv := newNegotiateServer()
+	rec := negotiate(t, srv, `{"service_id":"rust-service","constraint":"^v3.stable.0.stable.0.stable"}`)
+	if rec.Code != http.StatusNotAcceptable {
+		t.Fatalf("status = %d, want 406", rec.Code)
+	}
+	if _, ok := srv.peers.Lookup("rust-service"); ok {
+		t.Error("failed negotiation registered the peer")
+	}
+}
+
+func TestNegotiateUnknownPeer(t *testing.T) {
+	srv := newNegotiateServer()
+	rec := negotiate(t, srv, `{"service_id":"rust-service","constraint":"^v2.stable.0.stable.0.stable"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", rec.Code, rec.Body)
+	}
+	if p, ok := srv.peers.Lookup("rust-service"); ok {
+		t.Errorf("negotiation registered unseen peer as %+v", p)
+	}
+}
+
+func TestNegotiateSigned(t *testing.T) {
+	key := []byte("shared-secret")
+	cfg := DefaultConfig()
+	cfg.Secret = key
+	srv := NewServer(cfg, WithLogOutput(io.Discard))
+	req := NegotiateRequest{ServiceID: "rust-service", Constraint: "^v1.stable.0.stable.0.stable", Timestamp: 1700000000}
+	body := func(req NegotiateRequest) string {
+		b, _ := json.Marshal(req)
+		return string(b)
+	}
+
+	if rec := negotiate(t, srv, body(req)); rec.Code != http.StatusUnauthorized {
+		t.Errorf("unsigned: status = %d, want 401", rec.Code)
+	}
+	SignNegotiation(&req, key)
+	if rec := negotiate(t, srv, body(req)); rec.Code != http.StatusOK {
+		t.Errorf("signed: status = %d, body = %s", rec.Code, rec.Body)
+	}
+	tampered := req
+	tampered.Constraint = ">=v1.stable.0.stable.0.stable"
+	if rec := negotiate(t, srv, body(tampered)); rec.Code != http.StatusUnauthorized {
+		t.Errorf("tampered: status = %d, want 401", rec.Code)
+	}
+	// A message signature does not authorize a negotiation.
+	msg := ServiceMessage{ServiceID: req.ServiceID, Timestamp: req.Timestamp}
+	SignMessage(&msg, key)
+	tampered = req
+	tampered.Signature = msg.Signature
+	if rec := negotiate(t, srv, body(tampered)); rec.Code != http.StatusUnauthorized {
+		t.Errorf("message signature: status = %d, want 401", rec.Code)
+	}
+}
+
+func TestNegotiateBadRequest(t *testing.T) {
+	for name, tc := range map[string]struct{ body, code string }{
+		"malformed json":       {`{"constraint":`, ""},
+		"missing service id":   {`{"constraint":"^v1.stable.0.stable.0.stable"}`, ""},
+		"empty constraint":     {`{"service_id":"a","constraint":""}`, "empty_constraint"},
+		"malformed constraint": {`{"service_id":"a","constraint":">=1.0.0"}`, "malformed_version"},
+		"unknown channel":      {`{"service_id":"a","constraint":"^v1.nightly.0.stable.0.stable"}`, "unknown_channel"},
+		"contradictory":        {`{"service_id":"a","constraint":">v2.stable.0.stable.0.stable, <v1.stable.0.stable.0.stable"}`, "unsatisfiable_constraint"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			rec := negotiate(t, newNegotiateServer(), tc.body)
+			if rec.Code != http.StatusBadRequest {
+				t.Errorf("status = %d, want 400", rec.Code)
+			}
+			var resp map[string]string
+			json.NewDecoder(rec.Body).Decode(&resp)
+			if resp["code"] != tc.code {
+				t.Errorf("code = %q, want %q", resp["code"], tc.code)
+			}
+		})
+	}
+}
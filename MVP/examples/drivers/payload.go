@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// PayloadHandler processes an accepted message.
+type PayloadHandler func(ctx context.Context, msg ServiceMessage) error
+
+// PayloadCodec routes accepted messages to handlers by their MessageType.
+// Handlers registered with RegisterPayloadType receive the payload already
+// decoded; messages of any other type go to the default handler.
+type PayloadCodec struct {
+	mu       sync.RWMutex
+	handlers map[string]PayloadHandler
+	fallback PayloadHandler
+}
+
+// NewPayloadCodec returns a codec with no handlers whose default accepts
+// every message.
+func NewPayloadCodec() *PayloadCodec {
+	return &PayloadCodec{handlers: make(map[string]PayloadHandler)}
+}
+
+// PayloadDecodeError is returned when a payload does not decode into the
+// type registered for its MessageType.
+type PayloadDecodeError struct {
+	MessageType string
+	Err         error
+}
+
+func (e *PayloadDecodeError) Error() string {
+	return fmt.Sprintf("payload of type %q: %v", e.MessageType, e.Err)
+}
+
+func (e *PayloadDecodeError) Unwrap() error { return e.Err }
+
+// RegisterPayloadType makes c decode payloads of messageType into a T and
+// pass them to fn. The envelope is available to fn through
+// MessageFromContext. Registering a type again replaces its handler.
+func RegisterPayloadType[T any](c *PayloadCodec, messageType string, fn func(ctx context.Context, typed T) error) {
+	c.Handle(messageType, func(ctx context.Context, msg ServiceMessage) error {
+		var typed T
+		if err := json.Unmarshal(msg.Payload, &typed); err != nil {
+			return &PayloadDecodeError{MessageType: messageType, Err: err}
+		}
+		return fn(ctx, typed)
+	})
+}
+
+// Handle registers h for messages of messageType without decoding the
+// payload.
+func (c *PayloadCodec) Handle(messageType string, h PayloadHandler) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.handlers[messageType] = h
+}
+
+// SetDefault sets the handler for messages whose type has no registered
+// handler, including untyped ones. A nil h accepts them unprocessed.
+func (c *PayloadCodec) SetDefault(h PayloadHandler) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.fallback = h
+}
+
+// Dispatch hands msg to the handler for its type.
+func (c *PayloadCodec) Dispatch(ctx context.Context, msg ServiceMessage) error {
+	c.mu.RLock()
+	h, ok := c.handlers[msg.MessageType]
+	if !ok {
+		h = c.fallback
+	}
+	c.mu.RUnlock()
+	if h == nil {
+		return nil
+	}
+	return h(context.WithValue(ctx, messageKey{}, msg), msg)
+}
+
+type messageKey struct{}
+
+// MessageFromContext returns the message being dispatched, for handlers
+// that need the envelope alongside the typed payload.
+func MessageFromContext(ctx context.Context) (ServiceMessage, bool) {
+	msg, ok := ctx.Value(messageKey{}).(ServiceMessage)
+	return msg, ok
+}
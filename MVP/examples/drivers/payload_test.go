@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+type greeting struct {
+	Text string `json:"text"`
+}
+
+func TestPayloadDispatch(t *testing.T) {
+	srv := newTestServer()
+	var got []string
+	RegisterPayloadType(srv.Payloads(), "greeting", func(ctx context.Context, g greeting) error {
+		msg, _ := MessageFromContext(ctx)
+		got = append(got, msg.ServiceID+" says "+g.Text)
+		return nil
+	})
+	RegisterPayloadType(srv.Payloads(), "fail", func(ctx context.Context, _ json.RawMessage) error {
+		return errors.New("boom")
+	})
+	srv.Payloads().SetDefault(func(ctx context.Context, msg ServiceMessage) error {
+		got = append(got, "default "+msg.MessageType)
+		return nil
+	})
+
+	for _, tc := range []struct {
+		body string
+		code int
+	}{
+		{`{"service_id":"rust-service","version":"v1.stable.0.stable.0.stable","timestamp":1,"message_type":"greeting","payload":{"text":"hi"}}`, http.StatusOK},
+		{`{"service_id":"rust-service","version":"v1.stable.0.stable.0.stable","timestamp":1,"message_type":"unknown","payload":{}}`, http.StatusOK},
+		{`{"service_id":"rust-service","version":"v1.stable.0.stable.0.stable","timestamp":1,"payload":{}}`, http.StatusOK},
+		{`{"service_id":"rust-service","version":"v1.stable.0.stable.0.stable","timestamp":1,"message_type":"greeting","payload":{"text":1}}`, http.StatusBadRequest},
+		{`{"service_id":"rust-service","version":"v1.stable.0.stable.0.stable","timestamp":1,"message_type":"fail","payload":{}}`, http.StatusInternalServerError},
+	} {
+		if rec := postMessage(t, srv, tc.body); rec.Code != tc.code {
+			t.Errorf("%s: status = %d, want %d", tc.body, rec.Code, tc.code)
+		}
+	}
+	want := []string{"rust-service says hi", "default unknown", "default "}
+	if len(got) != len(want) {
+		t.Fatalf("dispatched %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("dispatch %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestPayloadDispatchNoDefault(t *testing.T) {
+	c := NewPayloadCodec()
+	if err := c.Dispatch(context.Background(), ServiceMessage{MessageType: "anything"}); err != nil {
+		t.Errorf("Dispatch without handlers = %v", err)
+	}
+	var de *PayloadDecodeError
+	RegisterPayloadType(c, "n", func(context.Context, int) error { return nil })
+	err := c.Dispatch(context.Background(), ServiceMessage{MessageType: "n", Payload: json.RawMessage(`"x"`)})
+	if !errors.As(err, &de) || de.MessageType != "n" {
+		t.Errorf("Dispatch of a bad payload = %v", err)
+	}
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/obinexus/rust-semverx/MVP/examples/drivers/semverx"
+)
+
+// Peer is a node this service has heard from.
+type Peer struct {
+	ServiceID string
+	Version   semverx.Version
+	LastSeen  time.Time
+	// Negotiated is the wire version agreed through /negotiate, if any.
+	Negotiated *semverx.Version
+}
+
+// PeerRegistry tracks known peers keyed by service ID. Peers not seen for
+// longer than the TTL are evicted. Every change is passed on to its Store.
+// It is safe for concurrent use.
+type PeerRegistry struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu         sync.Mutex
+	peers      map[string]Peer
+	expiry     expiryQueue // at least one entry per peer while ttl > 0
+	store      Store
+	storeError func(error)
+}
+
+// NewPeerRegistry returns an empty registry backed by a MemoryStore. A ttl
+// of zero or less keeps peers forever.
+func NewPeerRegistry(ttl time.Duration) *PeerRegistry {
+	return &PeerRegistry{ttl: ttl, now: time.Now, peers: make(map[string]Peer), store: NewMemoryStore()}
+}
+
+// UseStore rehydrates the registry from st and sends later changes to it.
+// Loaded peers that have already expired are dropped, and peers already
+// registered win over stored ones. Errors from later Save and Delete
+// calls go to onError, which may be nil. When Load fails st is still
+// attached, starting from the registry's current peers, and the error is
+// returned so the caller can report it.
+func (r *PeerRegistry) UseStore(st Store, onError func(error)) error {
+	loaded, err := st.Load()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.store, r.storeError = st, onError
+	now := r.now()
+	for _, p := range loaded {
+		if _, ok := r.peers[p.ServiceID]; ok {
+			continue
+		}
+		if r.expired(p, now) {
+			r.deleteLocked(p.ServiceID)
+			continue
+		}
+		r.peers[p.ServiceID] = p
+		r.queueLocked(p)
+	}
+	for _, p := range r.peers {
+		r.saveLocked(p)
+	}
+	return err
+}
+
+// Register records that id was seen now on version v.
+func (r *PeerRegistry) Register(id string, v semverx.Version) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := r.now()
+	r.pruneLocked(now)
+	p, ok := r.peers[id]
+	p.ServiceID, p.Version, p.LastSeen = id, v, now
+	r.peers[id] = p
+	if !ok {
+		r.queueLocked(p)
+	}
+	r.saveLocked(p)
+}
+
+// SetNegotiated caches the wire version agreed with id and reports whether
+// id is a registered peer. Nothing is recorded for a peer that has not sent
+// a message yet, since its own version is not known.
+func (r *PeerRegistry) SetNegotiated(id string, v semverx.Version) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := r.now()
+	r.pruneLocked(now)
+	p, ok := r.peers[id]
+	if !ok {
+		return false
+	}
+	p.LastSeen = now
+	p.Negotiated = &v
+	r.peers[id] = p
+	r.saveLocked(p)
+	return true
+}
+
+// Remove forgets id, for example when its stream connection closes.
+func (r *PeerRegistry) Remove(id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.deleteLocked(id)
+}
+
+// Lookup returns the peer registered under id, if it has not expired.
+func (r *PeerRegistry) Lookup(id string) (Peer, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	p, ok := r.peers[id]
+	if ok && r.expired(p, r.now()) {
+		r.deleteLocked(id)
+		return Peer{}, false
+	}
+	return p, ok
+}
+
+// List returns the live peers sorted by service ID.
+func (r *PeerRegistry) List() []Peer {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pruneLocked(r.now())
+	return sortedPeers(r.peers)
+}
+
+// pruneLocked drops the peers that have expired. A queued peer seen again
+// since is queued anew for its later expiry rather than on every sighting.
+func (r *PeerRegistry) pruneLocked(now time.Time) {
+	for {
+		id, ok := r.expiry.popExpired(now)
+		if !ok {
+			return
+		}
+		p, ok := r.peers[id]
+		switch {
+		case !ok:
+		case r.expired(p, now):
+			r.deleteLocked(id)
+		default:
+			r.queueLocked(p)
+		}
+	}
+}
+
+func (r *PeerRegistry) queueLocked(p Peer) {
+	if r.ttl > 0 {
+		r.expiry.add(p.ServiceID, p.LastSeen.Add(r.ttl))
+	}
+}
+
+func (r *PeerRegistry) saveLocked(p Peer) {
+	if err := r.store.Save(p); err != nil && r.storeError != nil {
+		r.storeError(fmt.Errorf("save peer %q: %w", p.ServiceID, err))
+	}
+}
+
+func (r *PeerRegistry) deleteLocked(id string) {
+	delete(r.peers, id)
+	if err := r.store.Delete(id); err != nil && r.storeError != nil {
+		r.storeError(fmt.Errorf("delete peer %q: %w", id, err))
+	}
+}
+
+func (r *PeerRegistry) expired(p Peer, now time.Time) bool {
+	return r.ttl > 0 && now.Sub(p.LastSeen) > r.ttl
+}
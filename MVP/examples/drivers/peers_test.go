@@ -0,0 +1,81 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/obinexus/rust-semverx/MVP/examples/drivers/semverx"
+)
+
+func TestPeerRegistry(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	r := NewPeerRegistry(time.Minute)
+	r.now = func() time.Time { return now }
+
+	v1 := semverx.MustParseVersion("v1.stable.0.stable.0.stable")
+	v2 := semverx.MustParseVersion("v1.stable.1.stable.0.stable")
+	r.Register("rust-service", v1)
+	r.Register("python-service", v1)
+	r.Register("rust-service", v2)
+
+	p, ok := r.Lookup("rust-service")
+	if !ok || !p.Version.Equal(v2) || !p.LastSeen.Equal(now) {
+		t.Errorf("Lookup(rust-service) = %+v, %v", p, ok)
+	}
+	if _, ok := r.Lookup("missing"); ok {
+		t.Error("Lookup(missing) succeeded")
+	}
+	list := r.List()
+	if len(list) != 2 || list[0].ServiceID != "python-service" || list[1].ServiceID != "rust-service" {
+		t.Errorf("List() = %+v", list)
+	}
+
+	now = now.Add(45 * time.Second)
+	r.Register("python-service", v1)
+	now = now.Add(30 * time.Second)
+	if _, ok := r.Lookup("rust-service"); ok {
+		t.Error("rust-service not evicted after TTL")
+	}
+	if list := r.List(); len(list) != 1 || list[0].ServiceID != "python-service" {
+		t.Errorf("List() after TTL = %+v", list)
+	}
+}
+
+func TestPeerRegistryNoTTL(t *testing.T) {
+	now := time.Unix(0, 0)
+	r := NewPeerRegistry(0)
+	r.now = func() time.Time { return now }
+	r.Register("a", semverx.MustParseVersion("v1.stable.0.stable.0.stable"))
+	now = now.Add(24 * time.Hour)
+	if _, ok := r.Lookup("a"); !ok {
+		t.Error("peer evicted with TTL disabled")
+	}
+}
+
+func TestPeerRegistryNegotiated(t *testing.T) {
+	r := NewPeerRegistry(time.Minute)
+	v1 := semverx.MustParseVersion("v1.stable.0.stable.0.stable")
+	v2 := semverx.MustParseVersion("v1.stable.2.stable.0.stable")
+
+	if r.SetNegotiated("new-peer", v1) {
+		t.Error("SetNegotiated accepted an unregistered peer")
+	}
+	if p, ok := r.Lookup("new-peer"); ok {
+		t.Errorf("SetNegotiated registered %+v", p)
+	}
+
+	r.Register("new-peer", v2)
+	if !r.SetNegotiated("new-peer", v1) {
+		t.Fatal("SetNegotiated rejected a registered peer")
+	}
+	p, ok := r.Lookup("new-peer")
+	if !ok || p.Negotiated == nil || !p.Negotiated.Equal(v1) || !p.Version.Equal(v2) {
+		t.Errorf("Lookup(new-peer) = %+v, %v", p, ok)
+	}
+
+	r.Register("new-peer", v2)
+	p, _ = r.Lookup("new-peer")
+	if !p.Version.Equal(v2) || p.Negotiated == nil || !p.Negotiated.Equal(v1) {
+		t.Errorf("Register dropped negotiated version: %+v", p)
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimit is a token bucket: Rate requests per second on average, with
+// bursts of up to Burst. A zero Rate disables limiting.
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+func (l RateLimit) enabled() bool { return l.Rate > 0 && l.Burst > 0 }
+
+// rateLimiter keeps one token bucket per sender. Requests without a sender
+// share a single bucket governed by the stricter anonymous limit.
+type rateLimiter struct {
+	known, anon RateLimit
+	now         func() time.Time
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	lastGC  time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(known, anon RateLimit) *rateLimiter {
+	return &rateLimiter{known: known, anon: anon, now: time.Now, buckets: make(map[string]*bucket)}
+}
+
+// allow takes a token from sender's bucket. When none is left it reports
+// how long until the next one is available.
+func (l *rateLimiter) allow(sender string) (bool, time.Duration) {
+	limit, key := l.known, "id:"+sender
+	if sender == "" {
+		limit, key = l.anon, "anon"
+	}
+	if !limit.enabled() {
+		return true, 0
+	}
+	now := l.now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.gc(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), last: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(float64(limit.Burst), b.tokens+now.Sub(b.last).Seconds()*limit.Rate)
+	b.last = now
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) / limit.Rate * float64(time.Second))
+	}
+	b.tokens--
+	return true, 0
+}
+
+// gc drops buckets that have been idle long enough to refill completely:
+// such a bucket behaves exactly like a fresh one. It runs at most once per
+// refill period of the slower limit.
+func (l *rateLimiter) gc(now time.Time) {
+	period := l.refillPeriod()
+	if now.Sub(l.lastGC) < period {
+		return
+	}
+	l.lastGC = now
+	for k, b := range l.buckets {
+		if now.Sub(b.last) >= period {
+			delete(l.buckets, k)
+		}
+	}
+}
+
+// refillPeriod is the longest time any bucket takes to go from empty to
+// full.
+func (l *rateLimiter) refillPeriod() time.Duration {
+	var d time.Duration
+	for _, lim := range [...]RateLimit{l.known, l.anon} {
+		if lim.enabled() {
+			if p := time.Duration(float64(lim.Burst) / lim.Rate * float64(time.Second)); p > d {
+				d = p
+			}
+		}
+	}
+	return d
+}
+
+// withRateLimit applies the per-sender rate limit. The sender is taken from
+// the X-Service-ID header or, failing that, from the service_id of the
+// body, as decoded by withMessage on /message and read here on other
+// routes; requests with neither use the anonymous bucket. Both are
+// self-declared, so this limits accidental floods rather than attackers.
+func (s *Server) withRateLimit(next http.Handler) http.Handler {
+	if s.limiter == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sender := r.Header.Get(serviceIDHeader)
+		if sender == "" {
+			d := requestMessage(r)
+			if d == nil {
+				body, err := io.ReadAll(r.Body)
+				if err != nil {
+					writeBodyError(w, "reading body", err)
+					return
+				}
+				r.Body = io.NopCloser(bytes.NewReader(body))
+				d = newDecodedMessage(r.Header.Get("Content-Type"), body)
+			}
+			if d.err == nil {
+				sender = d.msg.ServiceID
+			}
+		}
+		if ok, wait := s.limiter.allow(sender); !ok {
+			s.metrics.MessageRejected("rate_limited")
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			writeError(w, http.StatusTooManyRequests, "rate limit exceeded")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
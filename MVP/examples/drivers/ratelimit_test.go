@@ -0,0 +1,99 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterBuckets(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	l := newRateLimiter(RateLimit{Rate: 1, Burst: 2}, RateLimit{Rate: 0.5, Burst: 1})
+	l.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		if ok, _ := l.allow("a"); !ok {
+			t.Fatalf("request %d within burst was limited", i)
+		}
+	}
+	if ok, wait := l.allow("a"); ok || wait != time.Second {
+		t.Errorf("third request: ok = %v, wait = %s; want limited for 1s", ok, wait)
+	}
+	if ok, _ := l.allow("b"); !ok {
+		t.Error("another sender shares a's bucket")
+	}
+
+	if ok, _ := l.allow(""); !ok {
+		t.Fatal("first anonymous request was limited")
+	}
+	if ok, wait := l.allow(""); ok || wait != 2*time.Second {
+		t.Errorf("anonymous burst: ok = %v, wait = %s; want limited for 2s", ok, wait)
+	}
+
+	now = now.Add(time.Second)
+	if ok, _ := l.allow("a"); !ok {
+		t.Error("a was not refilled after 1s")
+	}
+}
+
+func TestRateLimiterGC(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	l := newRateLimiter(RateLimit{Rate: 1, Burst: 2}, RateLimit{})
+	l.now = func() time.Time { return now }
+	l.allow("quiet")
+	l.allow("chatty")
+
+	for i := 0; i < 4; i++ {
+		now = now.Add(time.Second / 2)
+		l.allow("chatty")
+	}
+	l.mu.Lock()
+	_, quiet := l.buckets["id:quiet"]
+	_, chatty := l.buckets["id:chatty"]
+	l.mu.Unlock()
+	if quiet || !chatty {
+		t.Errorf("after GC: quiet kept = %v, chatty kept = %v", quiet, chatty)
+	}
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.RateLimit = RateLimit{Rate: 0.1, Burst: 1}
+	cfg.AnonRateLimit = RateLimit{Rate: 0.1, Burst: 1}
+	// The limiter keys on the header alone, whether or not it is required.
+	cfg.RequiredHeaders = nil
+	h := NewServer(cfg, WithLogOutput(io.Discard)).Routes()
+	post := func(header, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/message", strings.NewReader(body))
+		if header != "" {
+			req.Header.Set("X-Service-ID", header)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+	msg := `{"service_id":"rust-service","version":"v1.stable.0.stable.0.stable","timestamp":1}`
+
+	if rec := post("", msg); rec.Code != http.StatusOK {
+		t.Fatalf("first message: status = %d", rec.Code)
+	}
+	rec := post("", msg)
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") != "10" {
+		t.Errorf("second message: status = %d, Retry-After = %q; want 429, 10", rec.Code, rec.Header().Get("Retry-After"))
+	}
+	if rec := post("rust-service", msg); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("header with the same sender: status = %d, want 429", rec.Code)
+	}
+	if rec := post("other", msg); rec.Code != http.StatusOK {
+		t.Errorf("header naming another sender: status = %d, want 200", rec.Code)
+	}
+	if rec := post("", "{"); rec.Code != http.StatusBadRequest {
+		t.Errorf("first anonymous request: status = %d, want 400 from the handler", rec.Code)
+	}
+	if rec := post("", "{"); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("second anonymous request: status = %d, want 429", rec.Code)
+	}
+}
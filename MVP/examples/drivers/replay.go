@@ -0,0 +1,127 @@
+package main
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Errors from replayGuard.reserve, reported as "code" by replayRejection.
+var (
+	errReplayed      = errors.New("replayed message")
+	errTimestampSkew = errors.New("timestamp outside replay window")
+)
+
+// replayGuard rejects messages whose Timestamp (Unix seconds) is outside
+// the allowed clock skew, and messages already accepted within that window.
+type replayGuard struct {
+	skew time.Duration
+	now  func() time.Time
+
+	mu     sync.Mutex
+	seen   map[string]time.Time // key -> time after which it can be forgotten
+	expiry expiryQueue
+}
+
+func newReplayGuard(skew time.Duration) *replayGuard {
+	return &replayGuard{skew: skew, now: time.Now, seen: make(map[string]time.Time)}
+}
+
+// reserve reports why msg must be rejected, if at all. Otherwise it holds
+// msg's key so that copies arriving while msg is processed are rejected,
+// and returns the key: the caller must pass it to release if msg is not
+// accepted after all, so that a resend of it is not taken for a replay.
+func (g *replayGuard) reserve(msg ServiceMessage) (string, error) {
+	now := g.now()
+	ts := time.Unix(msg.Timestamp, 0)
+	if d := now.Sub(ts); d > g.skew || d < -g.skew {
+		return "", fmt.Errorf("timestamp %d is outside the allowed clock skew of %s: %w", msg.Timestamp, g.skew, errTimestampSkew)
+	}
+
+	key := replayKey(msg)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for {
+		k, ok := g.expiry.popExpired(now)
+		if !ok {
+			break
+		}
+		if exp, ok := g.seen[k]; ok && now.After(exp) {
+			delete(g.seen, k)
+		}
+	}
+	if _, dup := g.seen[key]; dup {
+		return "", fmt.Errorf("duplicate message from %s at %d: %w", msg.ServiceID, msg.Timestamp, errReplayed)
+	}
+	// Once the timestamp falls out of the skew window the message is
+	// rejected anyway, so the entry is no longer needed.
+	g.seen[key] = ts.Add(g.skew)
+	g.expiry.add(key, ts.Add(g.skew))
+	return key, nil
+}
+
+// release forgets a key returned by reserve.
+func (g *replayGuard) release(key string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.seen, key)
+}
+
+// replayRejection is the rejection for an error from reserve. Both kinds
+// answer 409 like an incompatible version, so each carries its own code.
+func replayRejection(err error) *messageError {
+	merr := rejectMessage(http.StatusConflict, err.Error())
+	switch {
+	case errors.Is(err, errReplayed):
+		merr.fields["code"] = "replayed_message"
+	case errors.Is(err, errTimestampSkew):
+		merr.fields["code"] = "timestamp_skew"
+	}
+	return merr
+}
+
+// replayKey identifies a message by (ServiceID, Timestamp, Signature).
+// Unsigned messages use an unkeyed digest of the envelope in place of the
+// signature, so two messages that share a timestamp but differ in payload
+// are still told apart.
+func replayKey(msg ServiceMessage) string {
+	sig := msg.Signature
+	if sig == "" {
+		sig = hex.EncodeToString(messageMAC(msg, nil))
+	}
+	return fmt.Sprintf("%s|%d|%s", msg.ServiceID, msg.Timestamp, sig)
+}
+
+// withReplayProtection applies the replay guard, if enabled, to message
+// requests. A message only counts as seen once the handler accepts it with
+// a 2xx response. Bodies that did not decode in withMessage are passed
+// through so the wrapped handler reports the error.
+func (s *Server) withReplayProtection(next http.Handler) http.Handler {
+	if s.replay == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		d := requestMessage(r)
+		if d.err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		key, err := s.replay.reserve(d.msg)
+		if err != nil {
+			s.metrics.MessageRejected("replay")
+			merr := replayRejection(err)
+			writeJSON(w, merr.status, merr.fields)
+			return
+		}
+		rec := &statusRecorder{ResponseWriter: w}
+		defer func() {
+			if rec.status != 0 && (rec.status < 200 || rec.status > 299) {
+				s.replay.release(key)
+			}
+		}()
+		next.ServeHTTP(rec, r)
+	})
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/obinexus/rust-semverx/MVP/examples/drivers/semverx"
+)
+
+func newReplayServer(now time.Time) (*Server, http.Handler) {
+	cfg := DefaultConfig()
+	cfg.ReplayWindow = time.Minute
+	srv := NewServer(cfg, WithLogOutput(io.Discard))
+	srv.replay.now = func() time.Time { return now }
+	return srv, srv.Routes()
+}
+
+func send(t *testing.T, h http.Handler, msg ServiceMessage) int {
+	t.Helper()
+	b, err := json.Marshal(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, newMessageRequest("/message", string(b)))
+	return rec.Code
+}
+
+func TestReplayProtection(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	_, h := newReplayServer(now)
+	msg := ServiceMessage{
+		ServiceID: "rust-service",
+		Version:   semverx.MustParseVersion("v1.stable.0.stable.0.stable"),
+		Payload:   json.RawMessage(`{"n":1}`),
+		Timestamp: now.Unix(),
+	}
+
+	if code := send(t, h, msg); code != http.StatusOK {
+		t.Fatalf("first delivery: status = %d", code)
+	}
+	if code := send(t, h, msg); code != http.StatusConflict {
+		t.Errorf("replay: status = %d, want 409", code)
+	}
+
+	// Same timestamp, different payload: a distinct legitimate message.
+	other := msg
+	other.Payload = json.RawMessage(`{"n":2}`)
+	if code := send(t, h, other); code != http.StatusOK {
+		t.Errorf("same timestamp, different payload: status = %d", code)
+	}
+	// Same timestamp from another sender.
+	other = msg
+	other.ServiceID = "python-service"
+	if code := send(t, h, other); code != http.StatusOK {
+		t.Errorf("same timestamp, different sender: status = %d", code)
+	}
+
+	for _, ts := range []int64{now.Unix() - 61, now.Unix() + 61} {
+		stale := msg
+		stale.Timestamp = ts
+		if code := send(t, h, stale); code != http.StatusConflict {
+			t.Errorf("timestamp %d: status = %d, want 409", ts, code)
+		}
+	}
+	edge := msg
+	edge.Timestamp = now.Unix() - 60
+	if code := send(t, h, edge); code != http.StatusOK {
+		t.Errorf("timestamp at skew boundary: status = %d", code)
+	}
+}
+
+func TestReplayProtectionSigned(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	key := []byte("k")
+	cfg := DefaultConfig()
+	cfg.ReplayWindow = time.Minute
+	cfg.Secret = key
+	srv := NewServer(cfg, WithLogOutput(io.Discard))
+	srv.replay.now = func() time.Time { return now }
+	h := srv.Routes()
+
+	a := ServiceMessage{ServiceID: "rust-service", Version: semverx.MustParseVersion("v1.stable.0.stable.0.stable"), Payload: json.RawMessage(`"a"`), Timestamp: now.Unix()}
+	b := a
+	b.Payload = json.RawMessage(`"b"`)
+	SignMessage(&a, key)
+	SignMessage(&b, key)
+	for i, msg := range []ServiceMessage{a, b} {
+		if code := send(t, h, msg); code != http.StatusOK {
+			t.Errorf("message %d: status = %d", i, code)
+		}
+	}
+	if code := send(t, h, a); code != http.StatusConflict {
+		t.Errorf("replayed signed message: status = %d, want 409", code)
+	}
+}
+
+func TestReplayRecordsOnlyAccepted(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	srv, h := newReplayServer(now)
+	fail := true
+	srv.payloads.SetDefault(func(ctx context.Context, msg ServiceMessage) error {
+		if fail {
+			fail = false
+			return errors.New("store unavailable")
+		}
+		return nil
+	})
+	msg := ServiceMessage{
+		ServiceID: "rust-service",
+		Version:   semverx.MustParseVersion("v1.stable.0.stable.0.stable"),
+		Payload:   json.RawMessage(`{"n":1}`),
+		Timestamp: now.Unix(),
+	}
+	if code := send(t, h, msg); code != http.StatusInternalServerError {
+		t.Fatalf("failing delivery: status = %d, want 500", code)
+	}
+	if code := send(t, h, msg); code != http.StatusOK {
+		t.Errorf("resend after failure: status = %d, want 200", code)
+	}
+	if code := send(t, h, msg); code != http.StatusConflict {
+		t.Errorf("replay after acceptance: status = %d, want 409", code)
+	}
+}
+
+func TestReplayErrorCodes(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	_, h := newReplayServer(now)
+	msg := ServiceMessage{ServiceID: "rust-service", Version: semverx.MustParseVersion("v1.stable.0.stable.0.stable"), Timestamp: now.Unix()}
+	incompatible := msg
+	incompatible.Version = semverx.MustParseVersion("v2.stable.0.stable.0.stable")
+	stale := msg
+	stale.Timestamp = now.Unix() - 3600
+
+	send(t, h, msg)
+	for name, tc := range map[string]struct {
+		msg  ServiceMessage
+		code string
+	}{
+		"replay":       {msg, "replayed_message"},
+		"skew":         {stale, "timestamp_skew"},
+		"incompatible": {incompatible, "incompatible_version"},
+	} {
+		b, _ := json.Marshal(tc.msg)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, newMessageRequest("/message", string(b)))
+		var body map[string]string
+		json.NewDecoder(rec.Body).Decode(&body)
+		if rec.Code != http.StatusConflict || body["code"] != tc.code {
+			t.Errorf("%s: status = %d, code = %q, want 409 %q", name, rec.Code, body["code"], tc.code)
+		}
+	}
+}
+
+func TestReplayGuardForgets(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	g := newReplayGuard(time.Minute)
+	g.now = func() time.Time { return now }
+	msg := ServiceMessage{ServiceID: "a", Timestamp: now.Unix()}
+	if _, err := g.reserve(msg); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(2 * time.Minute)
+	g.reserve(ServiceMessage{ServiceID: "b", Timestamp: now.Unix()})
+	if len(g.seen) != 1 {
+		t.Errorf("expired entries not pruned: %v", g.seen)
+	}
+}
+
+func TestReplayProtectionDisabled(t *testing.T) {
+	srv := newTestServer()
+	if srv.replay != nil {
+		t.Fatal("replay protection enabled by default")
+	}
+	msg := ServiceMessage{ServiceID: "a", Version: semverx.MustParseVersion("v1.stable.0.stable.0.stable"), Timestamp: 1}
+	h := srv.Routes()
+	for i := 0; i < 2; i++ {
+		if code := send(t, h, msg); code != http.StatusOK {
+			t.Errorf("delivery %d: status = %d", i, code)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package semverx
+
+import (
+	"fmt"
+	"math"
+)
+
+// BumpPolicy controls how lower components are reset when a version is
+// bumped or promoted.
+type BumpPolicy struct {
+	// ResetChannel is the channel given to every component that is reset
+	// to zero.
+	ResetChannel Channel
+}
+
+// DefaultBumpPolicy is used by the Version bump methods.
+var DefaultBumpPolicy = BumpPolicy{ResetChannel: ChannelStable}
+
+// IncMajor returns v with Major incremented. Minor and Patch are zeroed and
+// take p.ResetChannel; the major channel is kept. Every iteration restarts
+// at 0. It fails with ErrOverflow when Major is already math.MaxInt.
+func (p BumpPolicy) IncMajor(v Version) (Version, error) {
+	if v.Major == math.MaxInt {
+		return v, overflow(v, "major")
+	}
+	v.Major, v.MajorIteration = v.Major+1, 0
+	v.Minor, v.MinorChannel, v.MinorIteration = 0, p.ResetChannel, 0
+	v.Patch, v.PatchChannel, v.PatchIteration = 0, p.ResetChannel, 0
+	return v, nil
+}
+
+// IncMinor returns v with Minor incremented. Patch is zeroed and takes
+// p.ResetChannel; the major and minor channels are kept. The minor and patch
+// iterations restart at 0. It fails with ErrOverflow when Minor is already
+// math.MaxInt.
+func (p BumpPolicy) IncMinor(v Version) (Version, error) {
+	if v.Minor == math.MaxInt {
+		return v, overflow(v, "minor")
+	}
+	v.Minor, v.MinorIteration = v.Minor+1, 0
+	v.Patch, v.PatchChannel, v.PatchIteration = 0, p.ResetChannel, 0
+	return v, nil
+}
+
+// IncPatch returns v with Patch incremented. Every channel is kept; the
+// patch iteration restarts at 0. It fails with ErrOverflow when Patch is
+// already math.MaxInt.
+func (p BumpPolicy) IncPatch(v Version) (Version, error) {
+	if v.Patch == math.MaxInt {
+		return v, overflow(v, "patch")
+	}
+	v.Patch, v.PatchIteration = v.Patch+1, 0
+	return v, nil
+}
+
+func overflow(v Version, component string) error {
+	return errorf(ErrOverflow, "semverx: cannot increment the %s number of %s past %d", component, v, math.MaxInt)
+}
+
+// Promote moves the most significant component of v whose channel ranks
+// below channel up to channel, and resets every less significant component
+// to zero on p.ResetChannel. The promoted component's iteration restarts at
+// 0. For example, promoting v1.stable.2.beta3.3.beta to rc gives
+// v1.stable.2.rc.0.stable.
+//
+// Promote never demotes: it returns an error when no component ranks below
+// channel, or when channel is not a known channel.
+func (p BumpPolicy) Promote(v Version, channel Channel) (Version, error) {
+	if !channel.valid() {
+		return v, fmt.Errorf("semverx: cannot promote %s to unknown channel %v", v, channel)
+	}
+	switch {
+	case v.MajorChannel.Rank() < channel.Rank():
+		v.MajorChannel, v.MajorIteration = channel, 0
+		v.Minor, v.MinorChannel, v.MinorIteration = 0, p.ResetChannel, 0
+		v.Patch, v.PatchChannel, v.PatchIteration = 0, p.ResetChannel, 0
+	case v.MinorChannel.Rank() < channel.Rank():
+		v.MinorChannel, v.MinorIteration = channel, 0
+		v.Patch, v.PatchChannel, v.PatchIteration = 0, p.ResetChannel, 0
+	case v.PatchChannel.Rank() < channel.Rank():
+		v.PatchChannel, v.PatchIteration = channel, 0
+	default:
+		return v, fmt.Errorf("semverx: cannot promote %s to %s: every component is already at least as stable", v, channel)
+	}
+	return v, nil
+}
+
+// IncMajor is DefaultBumpPolicy.IncMajor(v).
+func (v Version) IncMajor() (Version, error) { return DefaultBumpPolicy.IncMajor(v) }
+
+// IncMinor is DefaultBumpPolicy.IncMinor(v).
+func (v Version) IncMinor() (Version, error) { return DefaultBumpPolicy.IncMinor(v) }
+
+// IncPatch is DefaultBumpPolicy.IncPatch(v).
+func (v Version) IncPatch() (Version, error) { return DefaultBumpPolicy.IncPatch(v) }
+
+// Promote is DefaultBumpPolicy.Promote(v, channel).
+func (v Version) Promote(channel Channel) (Version, error) {
+	return DefaultBumpPolicy.Promote(v, channel)
+}
@@ -0,0 +1,119 @@
+package semverx
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+// mustBump returns the result of a bump that must succeed.
+func mustBump(t *testing.T) func(Version, error) Version {
+	return func(v Version, err error) Version {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+}
+
+func TestIncrement(t *testing.T) {
+	must := mustBump(t)
+	v := MustParseVersion("v1.rc.2.beta.3.alpha")
+	tests := []struct {
+		name string
+		got  Version
+		want string
+	}{
+		{"major", must(v.IncMajor()), "v2.rc.0.stable.0.stable"},
+		{"minor", must(v.IncMinor()), "v1.rc.3.beta.0.stable"},
+		{"patch", must(v.IncPatch()), "v1.rc.2.beta.4.alpha"},
+		{"minor then patch", must(must(v.IncMinor()).IncPatch()), "v1.rc.3.beta.1.stable"},
+		{"iterations restart", must(MustParseVersion("v1.stable.2.beta3.0.rc2").IncPatch()), "v1.stable.2.beta3.1.rc"},
+		{"custom reset", must(BumpPolicy{ResetChannel: ChannelExperimental}.IncMajor(v)), "v2.rc.0.experimental.0.experimental"},
+	}
+	for _, tt := range tests {
+		if tt.got.String() != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.name, tt.got, tt.want)
+		}
+	}
+	if v.String() != "v1.rc.2.beta.3.alpha" {
+		t.Errorf("bump mutated the receiver: %s", v)
+	}
+}
+
+func TestIncrementRollover(t *testing.T) {
+	must := mustBump(t)
+	v := MustParseVersion("v1.stable.9.stable.9.stable")
+	patch, minor, major := must(v.IncPatch()), must(v.IncMinor()), must(v.IncMajor())
+	if got := patch.String(); got != "v1.stable.9.stable.10.stable" {
+		t.Errorf("IncPatch = %s", got)
+	}
+	if got := minor.String(); got != "v1.stable.10.stable.0.stable" {
+		t.Errorf("IncMinor = %s", got)
+	}
+	if got := major.String(); got != "v2.stable.0.stable.0.stable" {
+		t.Errorf("IncMajor = %s", got)
+	}
+	if !v.Less(patch) || !patch.Less(minor) || !minor.Less(major) {
+		t.Error("bumps are not strictly increasing")
+	}
+
+	top := Version{Major: math.MaxInt, Minor: math.MaxInt, Patch: math.MaxInt, MajorChannel: ChannelStable, MinorChannel: ChannelStable, PatchChannel: ChannelStable}
+	for name, bump := range map[string]func() (Version, error){"IncMajor": top.IncMajor, "IncMinor": top.IncMinor, "IncPatch": top.IncPatch} {
+		if got, err := bump(); !errors.Is(err, ErrOverflow) || got != top {
+			t.Errorf("%s at MaxInt = %s, %v; want %s unchanged and ErrOverflow", name, got, err, top)
+		}
+	}
+	if _, err := (Version{Major: math.MaxInt, Minor: 1}).IncMinor(); err != nil {
+		t.Errorf("IncMinor below MaxInt failed: %v", err)
+	}
+}
+
+func TestPromote(t *testing.T) {
+	tests := []struct {
+		from    string
+		channel Channel
+		want    string
+	}{
+		{"v1.stable.2.beta.3.beta", ChannelRC, "v1.stable.2.rc.0.stable"},
+		{"v1.stable.2.rc.0.stable", ChannelStable, "v1.stable.2.stable.0.stable"},
+		{"v1.beta.2.stable.3.stable", ChannelRC, "v1.rc.0.stable.0.stable"},
+		{"v1.stable.2.stable.3.alpha", ChannelBeta, "v1.stable.2.stable.3.beta"},
+		{"v1.stable.2.alpha.3.alpha", ChannelStable, "v1.stable.2.stable.0.stable"},
+		{"v1.stable.2.beta3.3.beta1", ChannelRC, "v1.stable.2.rc.0.stable"},
+	}
+	for _, tt := range tests {
+		got, err := MustParseVersion(tt.from).Promote(tt.channel)
+		if err != nil {
+			t.Errorf("%s.Promote(%s): %v", tt.from, tt.channel, err)
+			continue
+		}
+		if got.String() != tt.want {
+			t.Errorf("%s.Promote(%s) = %s, want %s", tt.from, tt.channel, got, tt.want)
+		}
+	}
+}
+
+func TestPromoteRejectsDemotion(t *testing.T) {
+	tests := []struct {
+		from    string
+		channel Channel
+	}{
+		{"v1.stable.2.beta.0.stable", ChannelAlpha},
+		{"v1.stable.0.stable.0.stable", ChannelRC},
+		{"v1.stable.0.stable.0.stable", ChannelStable},
+		{"v1.beta.0.beta.0.beta", ChannelBeta},
+		{"v1.beta.0.beta.0.beta", ChannelUnknown},
+	}
+	for _, tt := range tests {
+		v := MustParseVersion(tt.from)
+		got, err := v.Promote(tt.channel)
+		if err == nil {
+			t.Errorf("%s.Promote(%v) = %s, want error", tt.from, tt.channel, got)
+		}
+		if got != v {
+			t.Errorf("%s.Promote(%v) changed the version on error: %s", tt.from, tt.channel, got)
+		}
+	}
+}
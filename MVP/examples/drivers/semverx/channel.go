@@ -0,0 +1,58 @@
+package semverx
+
+import "fmt"
+
+// Channel is the stability state attached to each version component.
+// Constants are declared from least to most stable, so Rank orders them.
+type Channel int
+
+const (
+	ChannelUnknown Channel = iota
+	ChannelLegacy
+	ChannelExperimental
+	ChannelAlpha
+	ChannelBeta
+	ChannelRC
+	ChannelStable
+)
+
+var channelTokens = [...]string{
+	ChannelLegacy:       "legacy",
+	ChannelExperimental: "experimental",
+	ChannelAlpha:        "alpha",
+	ChannelBeta:         "beta",
+	ChannelRC:           "rc",
+	ChannelStable:       "stable",
+}
+
+// ParseChannel returns the channel for a lowercase token such as "beta".
+func ParseChannel(tok string) (Channel, error) {
+	for c, t := range channelTokens {
+		if t != "" && t == tok {
+			return Channel(c), nil
+		}
+	}
+	return ChannelUnknown, errorf(ErrUnknownChannel, "unknown channel %q", tok)
+}
+
+// Rank orders channels by stability: a higher rank is more stable. Legacy
+// ranks lowest because it is deprecated and kept only for migration.
+// ChannelUnknown ranks below every known channel.
+func (c Channel) Rank() int {
+	if !c.valid() {
+		return 0
+	}
+	return int(c)
+}
+
+// String returns the lowercase token used in version strings.
+func (c Channel) String() string {
+	if c.valid() {
+		return channelTokens[c]
+	}
+	return fmt.Sprintf("Channel(%d)", int(c))
+}
+
+func (c Channel) valid() bool {
+	return c > ChannelUnknown && int(c) < len(channelTokens)
+}
@@ -0,0 +1,41 @@
+package semverx
+
+import "testing"
+
+func TestParseChannel(t *testing.T) {
+	for _, c := range []Channel{
+		ChannelLegacy, ChannelExperimental, ChannelAlpha,
+		ChannelBeta, ChannelRC, ChannelStable,
+	} {
+		got, err := ParseChannel(c.String())
+		if err != nil {
+			t.Fatalf("ParseChannel(%q): %v", c, err)
+		}
+		if got != c {
+			t.Errorf("ParseChannel(%q) = %v, want %v", c, got, c)
+		}
+	}
+	if c, _ := ParseChannel("beta"); c != ChannelBeta {
+		t.Errorf("ParseChannel(beta) = %v", c)
+	}
+	for _, tok := range []string{"", "Beta", "nightly", "Channel(0)"} {
+		if _, err := ParseChannel(tok); err == nil {
+			t.Errorf("ParseChannel(%q) succeeded, want error", tok)
+		}
+	}
+}
+
+func TestChannelRank(t *testing.T) {
+	order := []Channel{
+		ChannelUnknown, ChannelLegacy, ChannelExperimental,
+		ChannelAlpha, ChannelBeta, ChannelRC, ChannelStable,
+	}
+	for i := 1; i < len(order); i++ {
+		if order[i-1].Rank() >= order[i].Rank() {
+			t.Errorf("%v.Rank() >= %v.Rank()", order[i-1], order[i])
+		}
+	}
+	if Channel(99).Rank() != ChannelUnknown.Rank() {
+		t.Errorf("out-of-range channel should rank as unknown")
+	}
+}
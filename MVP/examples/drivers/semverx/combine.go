@@ -0,0 +1,185 @@
+package semverx
+
+// Intersect returns the constraint matched by exactly the versions that
+// satisfy both c and other: the terms of c followed by those of other,
+// less any implied by another term. Channel floors are kept, so
+// ^v1.stable.0.rc.0.stable intersected with ^v1.stable.0.beta.0.stable is
+// ^v1.stable.0.rc.0.stable. It reports false when the two ranges do not
+// overlap.
+func (c Constraint) Intersect(other Constraint) (Constraint, bool) {
+	all := append(append([]term(nil), c.terms...), other.terms...)
+	out := Constraint{terms: pruneImplied(all)}
+	lo, hi, ok := out.bounds()
+	if !ok {
+		return Constraint{}, false
+	}
+	// A single admissible version may still fail a channel floor.
+	if lo.set && hi.set && lo.inclusive && hi.inclusive && lo.v.Compare(hi.v) == 0 && !out.Matches(lo.v) {
+		return Constraint{}, false
+	}
+	return out, true
+}
+
+// Union returns a constraint matched by exactly the versions that satisfy c
+// or other, when one exists. That is the case when one constraint contains
+// the other, or when both are plain ranges of =, >, >=, < and <= terms that
+// overlap or touch and do not together cover every version. Otherwise
+// Union reports false.
+func (c Constraint) Union(other Constraint) (Constraint, bool) {
+	switch {
+	case c.implies(other):
+		return other, true
+	case other.implies(c):
+		return c, true
+	}
+	if !c.isPlainRange() || !other.isPlainRange() {
+		return Constraint{}, false
+	}
+	alo, ahi, _ := c.bounds()
+	blo, bhi, _ := other.bounds()
+	if lowerWithin(alo, blo) {
+		alo, ahi, blo, bhi = blo, bhi, alo, ahi
+	}
+	// Now a starts no later than b; they join unless a ends before b starts.
+	if ahi.set && blo.set {
+		cmp := ahi.v.Compare(blo.v)
+		if cmp < 0 || cmp == 0 && !ahi.inclusive && !blo.inclusive {
+			return Constraint{}, false
+		}
+	}
+	hi := ahi
+	if upperWithin(ahi, bhi) {
+		hi = bhi
+	}
+	return rangeConstraint(alo, hi)
+}
+
+// implies reports whether every version matching c also matches other.
+func (c Constraint) implies(other Constraint) bool {
+	for _, t := range other.terms {
+		implied := false
+		for _, u := range c.terms {
+			if u.implies(t) {
+				implied = true
+				break
+			}
+		}
+		if !implied {
+			return false
+		}
+	}
+	return true
+}
+
+// isPlainRange reports whether c only bounds versions numerically, with no
+// channel floor or wildcard.
+func (c Constraint) isPlainRange() bool {
+	for _, t := range c.terms {
+		if t.wild != 0 || t.op == OpCaret || t.op == OpTilde {
+			return false
+		}
+	}
+	return true
+}
+
+// rangeConstraint builds the constraint admitting exactly the versions
+// between lo and hi. A range open at both ends has no terms and cannot be
+// written down, so it is reported as unrepresentable.
+func rangeConstraint(lo, hi bound) (Constraint, bool) {
+	var c Constraint
+	if lo.set && hi.set && lo.inclusive && hi.inclusive && lo.v.Compare(hi.v) == 0 {
+		c.terms = []term{{op: OpEqual, v: lo.v}}
+		return c, true
+	}
+	if lo.set {
+		op := OpGreater
+		if lo.inclusive {
+			op = OpGreaterEqual
+		}
+		c.terms = append(c.terms, term{op: op, v: lo.v})
+	}
+	if hi.set {
+		op := OpLess
+		if hi.inclusive {
+			op = OpLessEqual
+		}
+		c.terms = append(c.terms, term{op: op, v: hi.v})
+	}
+	return c, len(c.terms) > 0
+}
+
+// pruneImplied drops every term implied by another one, keeping the first
+// of two terms that imply each other.
+func pruneImplied(terms []term) []term {
+	var out []term
+	for i, t := range terms {
+		redundant := false
+		for j, u := range terms {
+			if i == j || !u.implies(t) {
+				continue
+			}
+			if j < i || !t.implies(u) {
+				redundant = true
+				break
+			}
+		}
+		if !redundant {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// implies reports whether every version matching u also matches t. It is
+// conservative: false only means the implication could not be shown.
+func (u term) implies(t term) bool {
+	if u == t {
+		return true
+	}
+	if u.op == OpEqual && u.wild == 0 {
+		return t.matches(u.v)
+	}
+	if t.wild != 0 {
+		return false
+	}
+	// The bounds of u enclose every version it matches, so they are
+	// enough to show it stays within a range.
+	ulo, uhi := u.bounds()
+	tlo, thi := t.bounds()
+	switch t.op {
+	case OpGreater, OpGreaterEqual:
+		return lowerWithin(ulo, tlo)
+	case OpLess, OpLessEqual:
+		return upperWithin(uhi, thi)
+	case OpCaret, OpTilde:
+		hasFloor := u.wild == 0 && (u.op == OpCaret || u.op == OpTilde)
+		return hasFloor && lowerWithin(ulo, tlo) && upperWithin(uhi, thi) && meetsChannelFloor(u.v, t.v)
+	}
+	return false
+}
+
+// lowerWithin reports whether lower bound a admits nothing below what b
+// admits.
+func lowerWithin(a, b bound) bool {
+	switch {
+	case !b.set:
+		return true
+	case !a.set:
+		return false
+	}
+	cmp := a.v.Compare(b.v)
+	return cmp > 0 || cmp == 0 && (b.inclusive || !a.inclusive)
+}
+
+// upperWithin reports whether upper bound a admits nothing above what b
+// admits.
+func upperWithin(a, b bound) bool {
+	switch {
+	case !b.set:
+		return true
+	case !a.set:
+		return false
+	}
+	cmp := a.v.Compare(b.v)
+	return cmp < 0 || cmp == 0 && (b.inclusive || !a.inclusive)
+}
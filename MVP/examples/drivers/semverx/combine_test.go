@@ -0,0 +1,105 @@
+package semverx
+
+import "testing"
+
+func TestConstraintIntersect(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want string // "" when disjoint
+	}{
+		// Nested ranges keep the inner one.
+		{">=v1.stable.0.stable.0.stable, <v3.stable.0.stable.0.stable", ">=v1.stable.2.stable.0.stable, <v2.stable.0.stable.0.stable",
+			">=v1.stable.2.stable.0.stable, <v2.stable.0.stable.0.stable"},
+		// Overlapping ranges keep the tighter end of each side, in the
+		// order the terms were given.
+		{">=v1.stable.0.stable.0.stable, <v2.stable.0.stable.0.stable", ">v1.stable.5.stable.0.stable, <=v3.stable.0.stable.0.stable",
+			"<v2.stable.0.stable.0.stable, >v1.stable.5.stable.0.stable"},
+		// The stricter channel floor wins.
+		{"^v1.stable.0.rc.0.stable", "^v1.stable.0.beta.0.stable", "^v1.stable.0.rc.0.stable"},
+		{"^v1.stable.0.beta.0.stable", "^v1.stable.0.rc.0.stable", "^v1.stable.0.rc.0.stable"},
+		// Floors on different components are both kept.
+		{"^v1.stable.0.rc.0.beta", "^v1.stable.0.beta.0.rc", "^v1.stable.0.rc.0.beta, ^v1.stable.0.beta.0.rc"},
+		// A plain bound does not carry the floor, so both terms stay.
+		{"^v1.stable.2.stable.0.stable", ">=v1.stable.0.stable.0.stable, <v1.stable.5.stable.0.stable",
+			"^v1.stable.2.stable.0.stable, <v1.stable.5.stable.0.stable"},
+		{"v1.stable.*", "=v1.stable.3.stable.0.stable", "=v1.stable.3.stable.0.stable"},
+		{"^v1.stable.0.stable.0.stable", "^v1.stable.0.stable.0.stable", "^v1.stable.0.stable.0.stable"},
+
+		// Disjoint ranges.
+		{">=v1.stable.0.stable.0.stable, <v2.stable.0.stable.0.stable", ">=v2.stable.0.stable.0.stable", ""},
+		{"<=v1.stable.0.stable.0.stable", ">v1.stable.0.stable.0.stable", ""},
+		{"^v1.stable.0.stable.0.stable", "^v2.stable.0.stable.0.stable", ""},
+		{"~v1.stable.2.stable.0.stable", "v1.stable.3.*", ""},
+		// The one version in range is below the floor.
+		{"=v1.stable.3.beta.0.stable", "^v1.stable.0.stable.0.stable", ""},
+	}
+	for _, tt := range tests {
+		got, ok := mustConstraint(t, tt.a).Intersect(mustConstraint(t, tt.b))
+		if tt.want == "" {
+			if ok {
+				t.Errorf("%q ∩ %q = %q, want disjoint", tt.a, tt.b, got)
+			}
+			continue
+		}
+		if !ok || got.String() != tt.want {
+			t.Errorf("%q ∩ %q = %q, %v; want %q", tt.a, tt.b, got, ok, tt.want)
+		}
+	}
+}
+
+func TestConstraintIntersectMatches(t *testing.T) {
+	a := mustConstraint(t, "^v1.stable.2.stable.0.stable")
+	b := mustConstraint(t, "~v1.stable.4.beta.0.stable")
+	both, ok := a.Intersect(b)
+	if !ok {
+		t.Fatal("intersection reported disjoint")
+	}
+	for _, s := range []string{
+		"v1.stable.4.stable.0.stable",
+		"v1.stable.4.beta.0.stable",
+		"v1.stable.4.stable.9.stable",
+		"v1.stable.5.stable.0.stable",
+		"v1.stable.3.stable.0.stable",
+	} {
+		v := MustParseVersion(s)
+		if got, want := both.Matches(v), a.Matches(v) && b.Matches(v); got != want {
+			t.Errorf("intersection matches %s = %v, want %v", s, got, want)
+		}
+	}
+}
+
+func TestConstraintUnion(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want string // "" when not representable
+	}{
+		{">=v1.stable.0.stable.0.stable, <v2.stable.0.stable.0.stable", ">=v1.stable.5.stable.0.stable, <v3.stable.0.stable.0.stable",
+			">=v1.stable.0.stable.0.stable, <v3.stable.0.stable.0.stable"},
+		// Touching ranges join.
+		{">=v1.stable.0.stable.0.stable, <v2.stable.0.stable.0.stable", ">=v2.stable.0.stable.0.stable, <=v2.stable.5.stable.0.stable",
+			">=v1.stable.0.stable.0.stable, <=v2.stable.5.stable.0.stable"},
+		{"<v1.stable.0.stable.0.stable", "=v1.stable.0.stable.0.stable", "<=v1.stable.0.stable.0.stable"},
+		// A contained constraint, floor and all, is absorbed.
+		{"^v1.stable.2.rc.0.stable", "^v1.stable.0.beta.0.stable", "^v1.stable.0.beta.0.stable"},
+		{"v1.stable.*", ">=v0.stable.5.stable.0.stable", ">=v0.stable.5.stable.0.stable"},
+		// v1.stable.0.beta.0.stable matches the pattern but not the bound.
+		{"v1.stable.*", ">=v1.stable.0.stable.0.stable", ""},
+
+		// Gaps, floors that do not nest, and everything are not representable.
+		{"<v1.stable.0.stable.0.stable", ">v1.stable.0.stable.0.stable", ""},
+		{"^v1.stable.0.stable.0.stable", "^v2.stable.0.stable.0.stable", ""},
+		{"<v2.stable.0.stable.0.stable", ">=v1.stable.0.stable.0.stable", ""},
+	}
+	for _, tt := range tests {
+		got, ok := mustConstraint(t, tt.a).Union(mustConstraint(t, tt.b))
+		if tt.want == "" {
+			if ok {
+				t.Errorf("%q ∪ %q = %q, want not representable", tt.a, tt.b, got)
+			}
+			continue
+		}
+		if !ok || got.String() != tt.want {
+			t.Errorf("%q ∪ %q = %q, %v; want %q", tt.a, tt.b, got, ok, tt.want)
+		}
+	}
+}
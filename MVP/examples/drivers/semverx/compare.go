@@ -0,0 +1,57 @@
+package semverx
+
+import "cmp"
+
+// Compare returns -1, 0 or 1 depending on whether v orders before, equal to
+// or after other.
+//
+// Numbers always win: Major, Minor and Patch are compared first, in that
+// order, so v1.beta.3.beta.0.beta is newer than v1.stable.2.stable.9.stable.
+// Only when all three numbers are equal are the channels consulted, again
+// from major to patch. Each component is ordered by Channel.Rank and then,
+// within the same channel, by iteration. The first differing component
+// decides, so v1.stable.2.beta.0.stable < v1.stable.2.stable.0.stable, and
+// v1.stable.2.beta.0.stable > v1.beta.2.stable.0.stable because the major
+// channel is compared before the minor one.
+//
+// A channel without an iteration suffix is iteration 0: v1.beta.0.stable.0.stable
+// equals v1.beta0.0.stable.0.stable and orders before v1.beta1.0.stable.0.stable,
+// which in turn orders before v1.rc.0.stable.0.stable.
+//
+// Unknown channels all rank lowest. Between two of them the tokens a
+// Degraded version was parsed from are compared as strings, so that
+// v1.quantum.0.stable.0.stable and v1.tachyon.0.stable.0.stable, which are
+// not Equal, do not compare as 0 either.
+func (v Version) Compare(other Version) int {
+	vu, ou := v.unknownChannels(), other.unknownChannels()
+	for _, c := range [...]int{
+		cmp.Compare(v.Major, other.Major),
+		cmp.Compare(v.Minor, other.Minor),
+		cmp.Compare(v.Patch, other.Patch),
+		cmp.Compare(v.MajorChannel.Rank(), other.MajorChannel.Rank()),
+		cmp.Compare(vu[0], ou[0]),
+		cmp.Compare(v.MajorIteration, other.MajorIteration),
+		cmp.Compare(v.MinorChannel.Rank(), other.MinorChannel.Rank()),
+		cmp.Compare(vu[1], ou[1]),
+		cmp.Compare(v.MinorIteration, other.MinorIteration),
+		cmp.Compare(v.PatchChannel.Rank(), other.PatchChannel.Rank()),
+		cmp.Compare(vu[2], ou[2]),
+		cmp.Compare(v.PatchIteration, other.PatchIteration),
+	} {
+		if c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// Less reports whether v orders before other. It is suitable for sort.Slice.
+func (v Version) Less(other Version) bool {
+	return v.Compare(other) < 0
+}
+
+// Equal reports whether v and other denote the same version, that is
+// whether their Canonical forms match. It agrees with Compare returning 0.
+func (v Version) Equal(other Version) bool {
+	return v.Canonical() == other.Canonical()
+}
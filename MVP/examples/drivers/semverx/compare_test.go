@@ -0,0 +1,116 @@
+package semverx
+
+import (
+	"math"
+	"sort"
+	"testing"
+)
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"v1.stable.0.stable.0.stable", "v1.stable.0.stable.0.stable", 0},
+		{"v1.stable.0.stable.0.stable", "v2.stable.0.stable.0.stable", -1},
+		{"v1.stable.3.stable.0.stable", "v1.stable.2.stable.9.stable", 1},
+		{"v1.stable.2.stable.1.stable", "v1.stable.2.stable.2.stable", -1},
+		// Numbers are compared before any channel.
+		{"v1.beta.3.beta.0.beta", "v1.stable.2.stable.9.stable", 1},
+		// Same numbers: the first differing channel decides.
+		{"v1.stable.2.beta.0.stable", "v1.stable.2.stable.0.stable", -1},
+		{"v1.stable.2.stable.0.beta", "v1.stable.2.stable.0.rc", -1},
+		{"v1.stable.2.beta.0.stable", "v1.beta.2.stable.0.stable", 1},
+		{"v1.stable.0.stable.0.legacy", "v1.stable.0.stable.0.experimental", -1},
+		// Iterations order releases within a channel; none means 0.
+		{"v1.beta1.0.stable.0.stable", "v1.beta2.0.stable.0.stable", -1},
+		{"v1.beta.0.stable.0.stable", "v1.beta0.0.stable.0.stable", 0},
+		{"v1.beta.0.stable.0.stable", "v1.beta1.0.stable.0.stable", -1},
+		{"v1.beta9.0.stable.0.stable", "v1.rc.0.stable.0.stable", -1},
+		{"v1.stable.0.beta10.0.stable", "v1.stable.0.beta9.0.stable", 1},
+		{"v1.beta2.0.alpha.0.stable", "v1.beta1.0.stable.0.stable", 1},
+		{"v1.stable.0.stable.1.beta1", "v1.stable.0.stable.0.stable", 1},
+	}
+	for _, tt := range tests {
+		a, b := MustParseVersion(tt.a), MustParseVersion(tt.b)
+		if got := a.Compare(b); got != tt.want {
+			t.Errorf("%s.Compare(%s) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+		if got := b.Compare(a); got != -tt.want {
+			t.Errorf("%s.Compare(%s) = %d, want %d", tt.b, tt.a, got, -tt.want)
+		}
+		if a.Less(b) != (tt.want < 0) || a.Equal(b) != (tt.want == 0) {
+			t.Errorf("Less/Equal inconsistent with Compare for %s, %s", tt.a, tt.b)
+		}
+	}
+}
+
+func TestCompareExtremes(t *testing.T) {
+	// Differences between these overflow an int.
+	lo, hi := Version{Major: math.MinInt}, Version{Major: math.MaxInt}
+	if got := lo.Compare(hi); got != -1 {
+		t.Errorf("MinInt.Compare(MaxInt) = %d, want -1", got)
+	}
+	if got := hi.Compare(lo); got != 1 {
+		t.Errorf("MaxInt.Compare(MinInt) = %d, want 1", got)
+	}
+	lo, hi = Version{PatchIteration: math.MinInt}, Version{PatchIteration: 1}
+	if got := lo.Compare(hi); got != -1 {
+		t.Errorf("iteration MinInt.Compare(1) = %d, want -1", got)
+	}
+}
+
+func TestCompareDegraded(t *testing.T) {
+	lenient := func(s string) Version {
+		t.Helper()
+		v, err := ParseLenient(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+	for _, tt := range []struct {
+		a, b Version
+		want int
+	}{
+		{lenient("v1.quantum.0.stable.0.stable"), lenient("v1.tachyon.0.stable.0.stable"), -1},
+		{lenient("v1.stable.0.stable.0.quantum2"), lenient("v1.stable.0.stable.0.quantum10"), 1},
+		{lenient("v1.quantum.0.stable.0.stable"), lenient("1.quantum.0.stable.0.stable"), 0},
+		{lenient("v1.stable.0.stable.0.nightly"), lenient("v01.stable.0.stable.0.nightly"), 0},
+		{Version{Major: 1, MinorChannel: ChannelStable, PatchChannel: ChannelStable}, lenient("v1.quantum.0.stable.0.stable"), -1},
+		{lenient("v1.quantum.0.stable.0.stable"), MustParseVersion("v1.legacy.0.stable.0.stable"), -1},
+	} {
+		got := tt.a.Compare(tt.b)
+		if got != tt.want || tt.b.Compare(tt.a) != -tt.want {
+			t.Errorf("%s.Compare(%s) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+		if tt.a.Equal(tt.b) != (got == 0) {
+			t.Errorf("Equal(%s, %s) = %v, disagreeing with Compare", tt.a, tt.b, tt.a.Equal(tt.b))
+		}
+	}
+}
+
+func TestSortVersions(t *testing.T) {
+	in := []string{
+		"v2.stable.0.stable.0.stable",
+		"v1.stable.2.stable.0.stable",
+		"v1.stable.2.beta.0.stable",
+		"v1.stable.0.stable.0.stable",
+	}
+	vs := make([]Version, len(in))
+	for i, s := range in {
+		vs[i] = MustParseVersion(s)
+	}
+	sort.Slice(vs, func(i, j int) bool { return vs[i].Less(vs[j]) })
+	want := []string{
+		"v1.stable.0.stable.0.stable",
+		"v1.stable.2.beta.0.stable",
+		"v1.stable.2.stable.0.stable",
+		"v2.stable.0.stable.0.stable",
+	}
+	for i, v := range vs {
+		if v.String() != want[i] {
+			t.Errorf("sorted[%d] = %s, want %s", i, v, want[i])
+		}
+	}
+}
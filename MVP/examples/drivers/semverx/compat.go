@@ -0,0 +1,88 @@
+package semverx
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CompatibilityPolicy controls how far a remote version may drift from the
+// local one and still be considered compatible.
+type CompatibilityPolicy struct {
+	// MaxMinorSkew is the largest allowed difference between minor numbers.
+	// A negative value disables the check.
+	MaxMinorSkew int
+	// MaxPatchSkew is the largest allowed difference between patch numbers
+	// when the minor numbers are equal. A negative value disables the check.
+	MaxPatchSkew int
+}
+
+// DefaultCompatibilityPolicy is used by Compatible.
+var DefaultCompatibilityPolicy = CompatibilityPolicy{MaxMinorSkew: 2, MaxPatchSkew: -1}
+
+// CompatibilityReport explains the outcome of a compatibility check.
+type CompatibilityReport struct {
+	Local      Version
+	Remote     Version
+	Compatible bool
+	Reasons    []string
+}
+
+// Reason joins the individual reasons into a single message.
+func (r CompatibilityReport) Reason() string {
+	return strings.Join(r.Reasons, "; ")
+}
+
+// Compatible reports whether a peer on remote can talk to a node on local
+// under DefaultCompatibilityPolicy.
+func Compatible(local, remote Version) bool {
+	return DefaultCompatibilityPolicy.Check(local, remote).Compatible
+}
+
+// CheckCompatibility is Check under DefaultCompatibilityPolicy.
+func CheckCompatibility(local, remote Version) CompatibilityReport {
+	return DefaultCompatibilityPolicy.Check(local, remote)
+}
+
+// Check compares remote against local. Versions are compatible when:
+//
+//   - the major numbers are equal,
+//   - the least stable channel of remote ranks no lower than the least stable
+//     channel local declares, and
+//   - minor and patch numbers are within the policy's skew, and
+//   - neither version is Degraded: what an unrecognized channel promises
+//     is unknown, so such a version is never called compatible.
+//
+// Every failed rule is recorded in the report.
+func (p CompatibilityPolicy) Check(local, remote Version) CompatibilityReport {
+	r := CompatibilityReport{Local: local, Remote: remote}
+	for _, side := range [...]struct {
+		name string
+		v    Version
+	}{{"local", local}, {"remote", remote}} {
+		if side.v.Degraded {
+			r.Reasons = append(r.Reasons, fmt.Sprintf("%s version %s has unrecognized channels %s",
+				side.name, side.v, strings.Join(side.v.UnknownTokens(), ", ")))
+		}
+	}
+	if local.Major != remote.Major {
+		r.Reasons = append(r.Reasons, fmt.Sprintf("major version mismatch: local %d, remote %d", local.Major, remote.Major))
+	}
+	if min, got := local.LowestChannel(), remote.LowestChannel(); got.Rank() < min.Rank() {
+		r.Reasons = append(r.Reasons, fmt.Sprintf("channel downgrade: remote has %s, local requires at least %s", got, min))
+	}
+	if d := abs(local.Minor - remote.Minor); p.MaxMinorSkew >= 0 && d > p.MaxMinorSkew {
+		r.Reasons = append(r.Reasons, fmt.Sprintf("minor skew %d exceeds %d", d, p.MaxMinorSkew))
+	}
+	if d := abs(local.Patch - remote.Patch); local.Minor == remote.Minor && p.MaxPatchSkew >= 0 && d > p.MaxPatchSkew {
+		r.Reasons = append(r.Reasons, fmt.Sprintf("patch skew %d exceeds %d", d, p.MaxPatchSkew))
+	}
+	r.Compatible = len(r.Reasons) == 0
+	return r
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,65 @@
+package semverx
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCompatible(t *testing.T) {
+	tests := []struct {
+		local, remote string
+		want          bool
+		reason        string
+	}{
+		{"v1.stable.0.stable.0.stable", "v1.stable.0.stable.0.stable", true, ""},
+		{"v1.stable.2.stable.0.stable", "v1.stable.4.stable.9.stable", true, ""},
+		{"v1.stable.2.stable.0.stable", "v1.stable.0.stable.0.stable", true, ""},
+		{"v1.stable.0.stable.0.stable", "v2.stable.0.stable.0.stable", false, "major version mismatch"},
+		{"v2.stable.0.stable.0.stable", "v1.stable.0.stable.0.stable", false, "major version mismatch"},
+		{"v1.stable.0.stable.0.stable", "v1.stable.0.beta.0.stable", false, "channel downgrade"},
+		{"v1.stable.0.beta.0.stable", "v1.stable.0.stable.0.alpha", false, "channel downgrade"},
+		{"v1.stable.0.beta.0.stable", "v1.rc.0.stable.0.stable", true, ""},
+		{"v1.stable.0.stable.0.stable", "v1.stable.3.stable.0.stable", false, "minor skew"},
+	}
+	for _, tt := range tests {
+		local, remote := MustParseVersion(tt.local), MustParseVersion(tt.remote)
+		if got := Compatible(local, remote); got != tt.want {
+			t.Errorf("Compatible(%s, %s) = %v, want %v", tt.local, tt.remote, got, tt.want)
+		}
+		r := CheckCompatibility(local, remote)
+		if !strings.Contains(r.Reason(), tt.reason) {
+			t.Errorf("CheckCompatibility(%s, %s).Reason() = %q, want it to mention %q", tt.local, tt.remote, r.Reason(), tt.reason)
+		}
+	}
+}
+
+func TestCompatibilityPolicySkew(t *testing.T) {
+	p := CompatibilityPolicy{MaxMinorSkew: 0, MaxPatchSkew: 1}
+	local := MustParseVersion("v1.stable.2.stable.5.stable")
+	for remote, want := range map[string]bool{
+		"v1.stable.2.stable.6.stable": true,
+		"v1.stable.2.stable.7.stable": false,
+		"v1.stable.3.stable.5.stable": false,
+	} {
+		if got := p.Check(local, MustParseVersion(remote)).Compatible; got != want {
+			t.Errorf("Check(%s, %s) = %v, want %v", local, remote, got, want)
+		}
+	}
+
+	r := p.Check(local, MustParseVersion("v2.beta.9.stable.0.stable"))
+	if r.Compatible || len(r.Reasons) != 3 {
+		t.Errorf("want three reasons, got %q", r.Reasons)
+	}
+}
+
+func TestCompatibilityPolicyDegraded(t *testing.T) {
+	local := MustParseVersion("v1.stable.2.stable.0.stable")
+	remote, err := ParseLenient("v1.stable.2.stable.0.nightly")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := DefaultCompatibilityPolicy.Check(local, remote)
+	if r.Compatible || len(r.Reasons) == 0 || !strings.Contains(r.Reasons[0], "unrecognized channels nightly") {
+		t.Errorf("Check(%s, %s) = %+v, want a reason naming the unknown channel", local, remote, r)
+	}
+}
@@ -0,0 +1,321 @@
+package semverx
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Operator is a comparison operator in a Constraint term.
+type Operator int
+
+const (
+	OpEqual Operator = iota
+	OpGreater
+	OpGreaterEqual
+	OpLess
+	OpLessEqual
+	OpCaret
+	OpTilde
+)
+
+// Longer tokens come first so ">=" is not read as ">".
+var operatorTokens = []struct {
+	tok string
+	op  Operator
+}{
+	{">=", OpGreaterEqual},
+	{"<=", OpLessEqual},
+	{">", OpGreater},
+	{"<", OpLess},
+	{"=", OpEqual},
+	{"^", OpCaret},
+	{"~", OpTilde},
+}
+
+func (op Operator) String() string {
+	for _, t := range operatorTokens {
+		if t.op == op {
+			return t.tok
+		}
+	}
+	return fmt.Sprintf("Operator(%d)", int(op))
+}
+
+// Constraint is a conjunction of version terms, for example
+// ">=v1.stable.0.stable.0.stable, <v2.stable.0.stable.0.stable".
+//
+// Caret and tilde terms follow the usual semver ranges and additionally
+// act as a channel floor: every component of a matching version must be at
+// least as stable as the corresponding component of the base version.
+//
+//	^v1.stable.2.stable.0.stable  >=v1.stable.2.stable.0.stable, <v2, no channel below stable
+//	^v0.stable.2.stable.0.stable  >=v0.stable.2.stable.0.stable, <v0.3 (major 0 pins the minor)
+//	~v1.stable.2.beta.0.stable    >=v1.stable.2.beta.0.stable, <v1.3, minor channel at least beta
+//
+// A bare or "=" term may use "*" or "x" in place of a minor or patch number
+// or channel, and may end early in a trailing wildcard:
+//
+//	v1.stable.*.stable.*.stable   major 1, any minor and patch, every channel stable
+//	v1.stable.2.*.*.*             major 1 on stable, minor 2, any channel below
+//	v1.x                          major 1, any channels
+//
+// The major number can never be a wildcard. See parseWildcard for details.
+type Constraint struct {
+	terms []term
+}
+
+type term struct {
+	op Operator
+	v  Version
+	// wild marks the positions of v that match anything. Only OpEqual
+	// terms have wildcards.
+	wild wildMask
+}
+
+// ParseConstraint parses a comma-separated list of terms. A term without an
+// operator is treated as "=". Constraints that no version can satisfy, such
+// as ">v2.stable.0.stable.0.stable, <v1.stable.0.stable.0.stable", are
+// rejected.
+func ParseConstraint(s string) (Constraint, error) {
+	var c Constraint
+	if strings.TrimSpace(s) == "" {
+		return c, ErrEmptyConstraint
+	}
+	for _, raw := range strings.Split(s, ",") {
+		t, err := parseTerm(strings.TrimSpace(raw))
+		if err != nil {
+			return Constraint{}, fmt.Errorf("semverx: constraint %q: %w", s, err)
+		}
+		c.terms = append(c.terms, t)
+	}
+	if lo, hi, ok := c.bounds(); !ok {
+		return Constraint{}, errorf(ErrUnsatisfiable, "semverx: constraint %q is unsatisfiable: lower bound %s exceeds upper bound %s", s, lo, hi)
+	}
+	return c, nil
+}
+
+func parseTerm(s string) (term, error) {
+	if s == "" {
+		return term{}, errorf(ErrEmptyConstraint, "empty term")
+	}
+	t := term{op: OpEqual}
+	for _, o := range operatorTokens {
+		if strings.HasPrefix(s, o.tok) {
+			t.op = o.op
+			s = strings.TrimSpace(s[len(o.tok):])
+			break
+		}
+	}
+	if hasWildcard(s) {
+		if t.op != OpEqual {
+			return term{}, errorf(ErrMalformed, "wildcard version %q cannot be used with %s", s, t.op)
+		}
+		v, mask, err := parseWildcard(s)
+		if err != nil {
+			return term{}, err
+		}
+		t.v, t.wild = v, mask
+		return t, nil
+	}
+	v, err := ParseVersion(s)
+	if err != nil {
+		return term{}, err
+	}
+	t.v = v
+	return t, nil
+}
+
+// Matches reports whether v satisfies every term of the constraint.
+func (c Constraint) Matches(v Version) bool {
+	for _, t := range c.terms {
+		if !t.matches(v) {
+			return false
+		}
+	}
+	return true
+}
+
+// Satisfies parses versionStr and constraintStr and reports whether the
+// version matches the constraint. A parse failure is returned as an
+// *ArgumentError naming the argument at fault, never as a plain false.
+func Satisfies(versionStr, constraintStr string) (bool, error) {
+	v, err := ParseVersion(versionStr)
+	if err != nil {
+		return false, &ArgumentError{Arg: "version", Err: err}
+	}
+	c, err := ParseConstraint(constraintStr)
+	if err != nil {
+		return false, &ArgumentError{Arg: "constraint", Err: err}
+	}
+	return c.Matches(v), nil
+}
+
+// ArgumentError is returned by Satisfies when an argument does not parse.
+type ArgumentError struct {
+	Arg string // "version" or "constraint"
+	Err error
+}
+
+func (e *ArgumentError) Error() string {
+	return "semverx: invalid " + e.Arg + " argument: " + strings.TrimPrefix(e.Err.Error(), "semverx: ")
+}
+
+func (e *ArgumentError) Unwrap() error { return e.Err }
+
+// String returns the terms in canonical form, separated by ", ".
+func (c Constraint) String() string {
+	parts := make([]string, len(c.terms))
+	for i, t := range c.terms {
+		if t.wild != 0 {
+			parts[i] = formatPattern(t.v, t.wild)
+			continue
+		}
+		parts[i] = t.op.String() + t.v.String()
+	}
+	return strings.Join(parts, ", ")
+}
+
+func (t term) matches(v Version) bool {
+	if t.wild != 0 {
+		return matchesPattern(v, t.v, t.wild)
+	}
+	cmp := v.Compare(t.v)
+	switch t.op {
+	case OpEqual:
+		return cmp == 0
+	case OpGreater:
+		return cmp > 0
+	case OpGreaterEqual:
+		return cmp >= 0
+	case OpLess:
+		return cmp < 0
+	case OpLessEqual:
+		return cmp <= 0
+	case OpCaret, OpTilde:
+		_, hi := t.bounds()
+		return cmp >= 0 && (!hi.set || v.Compare(hi.v) < 0) && meetsChannelFloor(v, t.v)
+	}
+	return false
+}
+
+// meetsChannelFloor reports whether no component of v is less stable than
+// the same component of floor.
+func meetsChannelFloor(v, floor Version) bool {
+	return v.MajorChannel.Rank() >= floor.MajorChannel.Rank() &&
+		v.MinorChannel.Rank() >= floor.MinorChannel.Rank() &&
+		v.PatchChannel.Rank() >= floor.PatchChannel.Rank()
+}
+
+// bound is one end of the numeric range a term admits.
+type bound struct {
+	v         Version
+	inclusive bool
+	set       bool
+}
+
+func (b bound) String() string {
+	if !b.set {
+		return "(none)"
+	}
+	return b.v.String()
+}
+
+// bounds returns the range a single term admits. Unset bounds are open.
+func (t term) bounds() (lo, hi bound) {
+	if t.wild != 0 {
+		return patternBounds(t.v, t.wild)
+	}
+	switch t.op {
+	case OpEqual:
+		return bound{t.v, true, true}, bound{t.v, true, true}
+	case OpGreater:
+		return bound{t.v, false, true}, bound{}
+	case OpGreaterEqual:
+		return bound{t.v, true, true}, bound{}
+	case OpLess:
+		return bound{}, bound{t.v, false, true}
+	case OpLessEqual:
+		return bound{}, bound{t.v, true, true}
+	case OpCaret:
+		if t.v.Major == 0 {
+			return bound{t.v, true, true}, nextBound(0, t.v.Minor)
+		}
+		return bound{t.v, true, true}, nextBound(t.v.Major)
+	case OpTilde:
+		return bound{t.v, true, true}, nextBound(t.v.Major, t.v.Minor)
+	}
+	return bound{}, bound{}
+}
+
+// nextBound returns the exclusive upper bound just past every version whose
+// leading numbers, major first, are nums. A number already at math.MaxInt
+// carries into the one before it; with nowhere left to carry the bound is
+// unset, as no greater version exists.
+func nextBound(nums ...int) bound {
+	n := append([]int(nil), nums...)
+	for i := len(n) - 1; i >= 0; i-- {
+		if n[i] < math.MaxInt {
+			n[i]++
+			n = append(n[:i+1], 0, 0, 0)
+			return bound{Version{Major: n[0], Minor: n[1], Patch: n[2]}, false, true}
+		}
+	}
+	return bound{}
+}
+
+// bounds folds the terms into the tightest lower and upper bound and reports
+// whether the resulting range is non-empty. Upper bounds produced by caret
+// and tilde use ChannelUnknown, which ranks below every real channel, so
+// they exclude all versions with the next number.
+func (c Constraint) bounds() (lo, hi bound, ok bool) {
+	for _, t := range c.terms {
+		tlo, thi := t.bounds()
+		lo = tighterLower(lo, tlo)
+		hi = tighterUpper(hi, thi)
+	}
+	if !lo.set || !hi.set {
+		return lo, hi, true
+	}
+	switch cmp := lo.v.Compare(hi.v); {
+	case cmp < 0:
+		return lo, hi, true
+	case cmp == 0:
+		return lo, hi, lo.inclusive && hi.inclusive
+	}
+	return lo, hi, false
+}
+
+func tighterLower(a, b bound) bound {
+	switch {
+	case !a.set:
+		return b
+	case !b.set:
+		return a
+	}
+	switch cmp := a.v.Compare(b.v); {
+	case cmp > 0:
+		return a
+	case cmp < 0:
+		return b
+	}
+	a.inclusive = a.inclusive && b.inclusive
+	return a
+}
+
+func tighterUpper(a, b bound) bound {
+	switch {
+	case !a.set:
+		return b
+	case !b.set:
+		return a
+	}
+	switch cmp := a.v.Compare(b.v); {
+	case cmp < 0:
+		return a
+	case cmp > 0:
+		return b
+	}
+	a.inclusive = a.inclusive && b.inclusive
+	return a
+}
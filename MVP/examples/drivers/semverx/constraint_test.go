@@ -0,0 +1,166 @@
+package semverx
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+// maxInt is math.MaxInt as a version component, whatever the size of int.
+var maxInt = strconv.Itoa(math.MaxInt)
+
+func TestConstraintMatches(t *testing.T) {
+	tests := []struct {
+		constraint string
+		version    string
+		want       bool
+	}{
+		{"=v1.stable.0.stable.0.stable", "v1.stable.0.stable.0.stable", true},
+		{"v1.stable.0.stable.0.stable", "v1.stable.0.stable.1.stable", false},
+		{">v1.stable.0.stable.0.stable", "v1.stable.0.stable.1.stable", true},
+		{">v1.stable.0.stable.0.stable", "v1.stable.0.stable.0.stable", false},
+		{">=v1.stable.0.stable.0.stable", "v1.stable.0.stable.0.stable", true},
+		{"<v1.stable.0.stable.0.stable", "v0.stable.9.stable.9.stable", true},
+		{"<=v1.stable.0.stable.0.stable", "v1.stable.0.stable.0.beta", true},
+
+		{"^v1.stable.2.stable.0.stable", "v1.stable.2.stable.0.stable", true},
+		{"^v1.stable.2.stable.0.stable", "v1.stable.7.stable.3.stable", true},
+		{"^v1.stable.2.stable.0.stable", "v1.stable.1.stable.9.stable", false},
+		{"^v1.stable.2.stable.0.stable", "v2.stable.0.stable.0.stable", false},
+		{"^v1.stable.2.stable.0.stable", "v1.stable.3.beta.0.stable", false},
+		{"^v1.stable.2.stable.0.stable", "v1.stable.3.stable.0.rc", false},
+		{"^v1.stable.2.beta.0.stable", "v1.stable.3.rc.0.stable", true},
+		{"^v0.stable.2.stable.0.stable", "v0.stable.2.stable.5.stable", true},
+		{"^v0.stable.2.stable.0.stable", "v0.stable.3.stable.0.stable", false},
+		// Bounds at the largest number carry or stay open rather than
+		// overflowing.
+		{"^v" + maxInt + ".stable.0.stable.0.stable", "v" + maxInt + ".stable.0.stable.0.stable", true},
+		{"^v" + maxInt + ".stable.0.stable.0.stable", "v" + maxInt + ".stable.9.stable.0.stable", true},
+		{"^v0.stable." + maxInt + ".stable.0.stable", "v0.stable." + maxInt + ".stable.3.stable", true},
+		{"^v0.stable." + maxInt + ".stable.0.stable", "v1.stable.0.stable.0.stable", false},
+		{"~v1.stable." + maxInt + ".stable.0.stable", "v1.stable." + maxInt + ".stable.5.stable", true},
+		{"~v1.stable." + maxInt + ".stable.0.stable", "v2.stable.0.stable.0.stable", false},
+
+		{"~v1.stable.2.stable.0.stable", "v1.stable.2.stable.9.stable", true},
+		{"~v1.stable.2.stable.0.stable", "v1.stable.3.stable.0.stable", false},
+		{"~v1.stable.2.stable.0.stable", "v1.stable.2.stable.1.beta", false},
+
+		{">=v1.stable.0.stable.0.stable, <v2.stable.0.stable.0.stable", "v1.stable.9.stable.9.stable", true},
+		{">=v1.stable.0.stable.0.stable, <v2.stable.0.stable.0.stable", "v2.stable.0.stable.0.stable", false},
+		{">=v1.stable.0.stable.0.stable,<v2.stable.0.stable.0.stable", "v0.stable.9.stable.0.stable", false},
+		{"> v1.stable.0.stable.0.stable", "v1.stable.1.stable.0.stable", true},
+		{">=1.stable.0.stable.0.stable", "v1.stable.0.stable.0.stable", true},
+	}
+	for _, tt := range tests {
+		c, err := ParseConstraint(tt.constraint)
+		if err != nil {
+			t.Fatalf("ParseConstraint(%q): %v", tt.constraint, err)
+		}
+		if got := c.Matches(MustParseVersion(tt.version)); got != tt.want {
+			t.Errorf("%q.Matches(%s) = %v, want %v", tt.constraint, tt.version, got, tt.want)
+		}
+	}
+}
+
+func TestParseConstraintErrors(t *testing.T) {
+	for _, s := range []string{
+		"",
+		" , ",
+		">=",
+		">=1.stable.0.stable",
+		"!v1.stable.0.stable.0.stable",
+		">=v1.stable.0.stable.0.stable,",
+		// Contradictory ranges.
+		">v2.stable.0.stable.0.stable, <v1.stable.0.stable.0.stable",
+		">=v1.stable.0.stable.0.stable, <v1.stable.0.stable.0.stable",
+		"=v1.stable.0.stable.0.stable, =v1.stable.0.stable.1.stable",
+		"^v1.stable.0.stable.0.stable, >=v2.stable.0.stable.0.stable",
+		"~v1.stable.2.stable.0.stable, <v1.stable.2.stable.0.stable",
+	} {
+		if _, err := ParseConstraint(s); err == nil {
+			t.Errorf("ParseConstraint(%q) succeeded, want error", s)
+		}
+	}
+}
+
+func TestConstraintString(t *testing.T) {
+	c, err := ParseConstraint(" >= v1.stable.0.stable.0.stable ,^v1.stable.2.stable.0.stable")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := ">=v1.stable.0.stable.0.stable, ^v1.stable.2.stable.0.stable"
+	if got := c.String(); got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
+func TestSatisfies(t *testing.T) {
+	tests := []struct {
+		version, constraint string
+		want                bool
+		badArg              string
+	}{
+		{"v1.stable.2.stable.0.stable", "^v1.stable.0.stable.0.stable", true, ""},
+		{"1.stable.2.stable.0.stable", ">=v1.stable.0.stable.0.stable, <v2.stable.0.stable.0.stable", true, ""},
+		{"v2.stable.0.stable.0.stable", "^v1.stable.0.stable.0.stable", false, ""},
+		{"v1.stable.2", "^v1.stable.0.stable.0.stable", false, "version"},
+		{"v1.stable.2.stable.0.nightly", "^v1.stable.0.stable.0.stable", false, "version"},
+		{"v1.stable.2.stable.0.stable", "^v1.stable", false, "constraint"},
+		{"v1.stable.2.stable.0.stable", "", false, "constraint"},
+		// The version is checked first.
+		{"bogus", "bogus", false, "version"},
+	}
+	for _, tt := range tests {
+		got, err := Satisfies(tt.version, tt.constraint)
+		var ae *ArgumentError
+		switch {
+		case tt.badArg == "" && err != nil:
+			t.Errorf("Satisfies(%q, %q): %v", tt.version, tt.constraint, err)
+		case tt.badArg != "" && (!errors.As(err, &ae) || ae.Arg != tt.badArg || ae.Unwrap() == nil):
+			t.Errorf("Satisfies(%q, %q) error = %v, want an ArgumentError for the %s", tt.version, tt.constraint, err, tt.badArg)
+		case got != tt.want:
+			t.Errorf("Satisfies(%q, %q) = %v, want %v", tt.version, tt.constraint, got, tt.want)
+		}
+	}
+
+	_, err := Satisfies("v1.stable.x.stable.0.stable", "^v1.stable.0.stable.0.stable")
+	if want := `semverx: invalid version argument: version "v1.stable.x.stable.0.stable": invalid numeric component "x"`; err == nil || err.Error() != want {
+		t.Errorf("error = %v, want %s", err, want)
+	}
+}
+
+func FuzzParseConstraint(f *testing.F) {
+	for _, seed := range []string{
+		"^v1.stable.0.stable.0.stable",
+		"~v1.stable.2.beta3.0.stable",
+		">=v1.stable.0.rc.0.stable, <v2.stable.0.stable.0.stable",
+		"> v0.legacy.1.experimental.2.alpha, <= v3.stable.0.stable.0.stable",
+		"=v1.stable.0.alpha2.0.stable",
+		"v1.stable.*.stable.*.stable",
+		"v1.x",
+		"v1.stable.2.*",
+		">=v2.3.4",
+		"",
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		c, err := ParseConstraint(s)
+		if err != nil {
+			return
+		}
+		out := c.String()
+		d, err := ParseConstraint(out)
+		if err != nil {
+			t.Fatalf("ParseConstraint(%q) = %q, which does not parse: %v", s, out, err)
+		}
+		if !reflect.DeepEqual(c, d) {
+			t.Fatalf("ParseConstraint(%q) = %+v, but its String %q parses to %+v", s, c, out, d)
+		}
+		if d.String() != out {
+			t.Fatalf("String not stable for %q: %q then %q", s, out, d.String())
+		}
+	})
+}
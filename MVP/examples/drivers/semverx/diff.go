@@ -0,0 +1,129 @@
+package semverx
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DiffKind classifies a VersionDiff by its most significant change.
+type DiffKind string
+
+const (
+	DiffNone        DiffKind = "none"
+	DiffChannelOnly DiffKind = "channel-only"
+	DiffPatch       DiffKind = "patch"
+	DiffMinor       DiffKind = "minor"
+	DiffMajor       DiffKind = "major"
+)
+
+// ChannelShift is the direction a component's channel moved in.
+type ChannelShift int
+
+const (
+	ShiftNone ChannelShift = iota
+	ShiftPromotion
+	ShiftDemotion
+)
+
+func (s ChannelShift) String() string {
+	switch s {
+	case ShiftPromotion:
+		return "promotion"
+	case ShiftDemotion:
+		return "demotion"
+	}
+	return "none"
+}
+
+// ComponentDiff describes how one of major, minor and patch changed.
+type ComponentDiff struct {
+	Component     string // "major", "minor" or "patch"
+	From, To      int
+	FromChannel   Channel
+	ToChannel     Channel
+	FromIteration int
+	ToIteration   int
+	// Shift orders the channels by rank and then iteration, as Compare
+	// does, so beta1 -> beta2 is a promotion.
+	Shift ChannelShift
+}
+
+// NumberChanged reports whether the component's number differs.
+func (c ComponentDiff) NumberChanged() bool { return c.From != c.To }
+
+// VersionDiff explains what changed between two versions.
+type VersionDiff struct {
+	From, To Version
+	// Components holds the major, minor and patch diffs in that order.
+	Components [3]ComponentDiff
+	Kind       DiffKind
+	// Breaking is set when the major number changes or any channel is
+	// demoted, even if the numbers are unchanged.
+	Breaking bool
+}
+
+// Diff compares from with to.
+func Diff(from, to Version) VersionDiff {
+	d := VersionDiff{From: from, To: to, Kind: DiffNone}
+	d.Components = [3]ComponentDiff{
+		diffComponent("major", from.Major, to.Major, from.MajorChannel, to.MajorChannel, from.MajorIteration, to.MajorIteration),
+		diffComponent("minor", from.Minor, to.Minor, from.MinorChannel, to.MinorChannel, from.MinorIteration, to.MinorIteration),
+		diffComponent("patch", from.Patch, to.Patch, from.PatchChannel, to.PatchChannel, from.PatchIteration, to.PatchIteration),
+	}
+	for i, kind := range [...]DiffKind{DiffMajor, DiffMinor, DiffPatch} {
+		if d.Components[i].NumberChanged() {
+			d.Kind = kind
+			break
+		}
+	}
+	for _, c := range d.Components {
+		if c.Shift == ShiftDemotion {
+			d.Breaking = true
+		}
+		if d.Kind == DiffNone && c.Shift != ShiftNone {
+			d.Kind = DiffChannelOnly
+		}
+	}
+	if d.Kind == DiffMajor {
+		d.Breaking = true
+	}
+	return d
+}
+
+func diffComponent(name string, from, to int, fromCh, toCh Channel, fromIt, toIt int) ComponentDiff {
+	c := ComponentDiff{
+		Component: name, From: from, To: to,
+		FromChannel: fromCh, ToChannel: toCh,
+		FromIteration: fromIt, ToIteration: toIt,
+	}
+	switch r := toCh.Rank() - fromCh.Rank(); {
+	case r > 0, r == 0 && toIt > fromIt:
+		c.Shift = ShiftPromotion
+	case r < 0, r == 0 && toIt < fromIt:
+		c.Shift = ShiftDemotion
+	}
+	return c
+}
+
+// String summarises the diff, e.g.
+// "v1.stable.0.stable.0.stable -> v1.stable.1.beta.0.stable: minor, breaking (minor 0 -> 1; minor channel stable -> beta, demotion)".
+func (d VersionDiff) String() string {
+	head := fmt.Sprintf("%s -> %s: %s", d.From, d.To, d.Kind)
+	if d.Breaking {
+		head += ", breaking"
+	}
+	var changes []string
+	for _, c := range d.Components {
+		if c.NumberChanged() {
+			changes = append(changes, fmt.Sprintf("%s %d -> %d", c.Component, c.From, c.To))
+		}
+		if c.Shift != ShiftNone {
+			changes = append(changes, fmt.Sprintf("%s channel %s -> %s, %s", c.Component,
+				formatChannel(c.FromChannel, c.FromIteration), formatChannel(c.ToChannel, c.ToIteration), c.Shift))
+		}
+	}
+	if len(changes) == 0 {
+		return head
+	}
+	return head + " (" + strings.Join(changes, "; ") + ")"
+}
@@ -0,0 +1,56 @@
+package semverx
+
+import "testing"
+
+func TestDiffKinds(t *testing.T) {
+	for _, tc := range []struct {
+		from, to string
+		kind     DiffKind
+		breaking bool
+	}{
+		{"v1.stable.0.stable.0.stable", "v1.stable.0.stable.0.stable", DiffNone, false},
+		{"v1.stable.0.stable.0.stable", "v2.stable.0.stable.0.stable", DiffMajor, true},
+		{"v2.stable.0.stable.0.stable", "v1.stable.9.stable.0.stable", DiffMajor, true},
+		{"v1.stable.0.stable.0.stable", "v1.stable.1.stable.0.stable", DiffMinor, false},
+		{"v1.stable.0.stable.0.stable", "v1.stable.0.stable.3.stable", DiffPatch, false},
+		{"v1.stable.0.beta.0.stable", "v1.stable.0.stable.0.stable", DiffChannelOnly, false},
+		{"v1.stable.0.beta1.0.stable", "v1.stable.0.beta2.0.stable", DiffChannelOnly, false},
+		{"v1.stable.0.stable.0.stable", "v1.stable.0.stable.0.rc", DiffChannelOnly, true},
+		{"v1.stable.0.beta2.0.stable", "v1.stable.0.beta1.0.stable", DiffChannelOnly, true},
+		{"v1.stable.0.stable.0.stable", "v1.stable.1.alpha.0.stable", DiffMinor, true},
+	} {
+		d := Diff(MustParseVersion(tc.from), MustParseVersion(tc.to))
+		if d.Kind != tc.kind || d.Breaking != tc.breaking {
+			t.Errorf("Diff(%s, %s) = %s, breaking %v; want %s, %v", tc.from, tc.to, d.Kind, d.Breaking, tc.kind, tc.breaking)
+		}
+	}
+}
+
+func TestDiffComponents(t *testing.T) {
+	d := Diff(MustParseVersion("v1.beta.2.stable.0.stable"), MustParseVersion("v1.stable.3.rc.0.stable"))
+	major, minor, patch := d.Components[0], d.Components[1], d.Components[2]
+	if major.NumberChanged() || major.Shift != ShiftPromotion || major.FromChannel != ChannelBeta || major.ToChannel != ChannelStable {
+		t.Errorf("major = %+v", major)
+	}
+	if !minor.NumberChanged() || minor.From != 2 || minor.To != 3 || minor.Shift != ShiftDemotion {
+		t.Errorf("minor = %+v", minor)
+	}
+	if patch.NumberChanged() || patch.Shift != ShiftNone {
+		t.Errorf("patch = %+v", patch)
+	}
+}
+
+func TestDiffString(t *testing.T) {
+	for _, tc := range []struct{ from, to, want string }{
+		{"v1.stable.0.stable.0.stable", "v1.stable.0.stable.0.stable",
+			"v1.stable.0.stable.0.stable -> v1.stable.0.stable.0.stable: none"},
+		{"v1.stable.0.stable.0.stable", "v1.stable.1.beta.0.stable",
+			"v1.stable.0.stable.0.stable -> v1.stable.1.beta.0.stable: minor, breaking (minor 0 -> 1; minor channel stable -> beta, demotion)"},
+		{"v1.stable.0.beta1.0.stable", "v1.stable.0.beta2.0.stable",
+			"v1.stable.0.beta1.0.stable -> v1.stable.0.beta2.0.stable: channel-only (minor channel beta1 -> beta2, promotion)"},
+	} {
+		if got := Diff(MustParseVersion(tc.from), MustParseVersion(tc.to)).String(); got != tc.want {
+			t.Errorf("got  %s\nwant %s", got, tc.want)
+		}
+	}
+}
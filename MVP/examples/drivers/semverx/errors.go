@@ -0,0 +1,52 @@
+package semverx
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Errors returned, possibly wrapped, by ParseVersion, ParseConstraint, the
+// functions built on them and the bump methods. Test for them with
+// errors.Is; the message of the returned error describes the offending
+// input.
+var (
+	// ErrEmptyVersion is returned for an empty version string.
+	ErrEmptyVersion = errors.New("semverx: empty version")
+	// ErrMissingPrefix is returned by ParseVersionStrict for a version
+	// without the 'v' prefix.
+	ErrMissingPrefix = errors.New("semverx: missing 'v' prefix")
+	// ErrUnknownChannel is returned for a channel token outside the known
+	// set, such as "nightly".
+	ErrUnknownChannel = errors.New("semverx: unknown channel")
+	// ErrBadComponent is returned for a numeric component or channel
+	// iteration that is not a non-negative decimal number.
+	ErrBadComponent = errors.New("semverx: invalid component")
+	// ErrMalformed is returned when the input does not have the shape of a
+	// version, pattern or term, e.g. the wrong number of parts.
+	ErrMalformed = errors.New("semverx: malformed version")
+	// ErrEmptyConstraint is returned by ParseConstraint for an empty
+	// constraint or term.
+	ErrEmptyConstraint = errors.New("semverx: empty constraint")
+	// ErrUnsatisfiable is returned by ParseConstraint for a constraint no
+	// version can satisfy.
+	ErrUnsatisfiable = errors.New("semverx: unsatisfiable constraint")
+	// ErrOverflow is returned by IncMajor, IncMinor and IncPatch for a
+	// number already at math.MaxInt.
+	ErrOverflow = errors.New("semverx: version number overflow")
+)
+
+// kindError carries its own message while matching one of the sentinel
+// errors under errors.Is.
+type kindError struct {
+	kind error
+	msg  string
+}
+
+func (e *kindError) Error() string { return e.msg }
+func (e *kindError) Unwrap() error { return e.kind }
+
+// errorf formats an error of the given kind. Unlike fmt.Errorf it does not
+// wrap its arguments.
+func errorf(kind error, format string, args ...any) error {
+	return &kindError{kind: kind, msg: fmt.Sprintf(format, args...)}
+}
@@ -0,0 +1,58 @@
+package semverx
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseVersionErrorKinds(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		want error
+	}{
+		{"", ErrEmptyVersion},
+		{"v1.stable.0.stable", ErrMalformed},
+		{"v1.stable.0.stable.0.stable.0", ErrMalformed},
+		{"v1.nightly.0.stable.0.stable", ErrUnknownChannel},
+		{"v1.stable.0.stable.0.", ErrUnknownChannel},
+		{"vX.stable.0.stable.0.stable", ErrBadComponent},
+		{"v1.stable.-1.stable.0.stable", ErrBadComponent},
+		{"v1.stable.0.beta99999999999999999999.0.stable", ErrBadComponent},
+	} {
+		_, err := ParseVersion(tc.in)
+		if !errors.Is(err, tc.want) {
+			t.Errorf("ParseVersion(%q) = %v, want errors.Is %v", tc.in, err, tc.want)
+		}
+	}
+	if _, err := ParseVersionStrict("1.stable.0.stable.0.stable"); !errors.Is(err, ErrMissingPrefix) {
+		t.Errorf("ParseVersionStrict without prefix = %v, want ErrMissingPrefix", err)
+	}
+}
+
+func TestParseConstraintErrorKinds(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		want error
+	}{
+		{" ", ErrEmptyConstraint},
+		{">=v1.stable.0.stable.0.stable,", ErrEmptyConstraint},
+		{">v2.stable.0.stable.0.stable, <v1.stable.0.stable.0.stable", ErrUnsatisfiable},
+		{"^v1.nightly.0.stable.0.stable", ErrUnknownChannel},
+		{">=v1.stable.07x.stable.0.stable", ErrBadComponent},
+		{">=v1.stable.0", ErrMalformed},
+		{">v1.x", ErrMalformed},
+		{"v1.stable.2", ErrMalformed},
+		{"v1.stable.*.nightly.*.*", ErrUnknownChannel},
+	} {
+		_, err := ParseConstraint(tc.in)
+		if !errors.Is(err, tc.want) {
+			t.Errorf("ParseConstraint(%q) = %v, want errors.Is %v", tc.in, err, tc.want)
+		}
+	}
+
+	_, err := Satisfies("v1.stable.0.stable.0.stable", "^v1.nightly.0.stable.0.stable")
+	var ae *ArgumentError
+	if !errors.As(err, &ae) || ae.Arg != "constraint" || !errors.Is(err, ErrUnknownChannel) {
+		t.Errorf("Satisfies = %v, want a constraint ArgumentError matching ErrUnknownChannel", err)
+	}
+}
@@ -0,0 +1,38 @@
+package semverx
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// IsZero reports whether v is the zero Version, i.e. no version at all.
+func (v Version) IsZero() bool {
+	return v == Version{}
+}
+
+// MarshalJSON encodes v as its canonical string. The zero Version encodes
+// as null.
+func (v Version) MarshalJSON() ([]byte, error) {
+	if v.IsZero() {
+		return []byte("null"), nil
+	}
+	return json.Marshal(v.String())
+}
+
+// UnmarshalJSON decodes a version string such as
+// "v1.stable.0.stable.0.stable". null leaves v unchanged.
+func (v *Version) UnmarshalJSON(b []byte) error {
+	if bytes.Equal(b, []byte("null")) {
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return errorf(ErrMalformed, "semverx: version must be a JSON string, got %s", b)
+	}
+	parsed, err := ParseVersion(s)
+	if err != nil {
+		return err
+	}
+	*v = parsed
+	return nil
+}
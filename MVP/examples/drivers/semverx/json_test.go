@@ -0,0 +1,54 @@
+package semverx
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestVersionJSON(t *testing.T) {
+	type envelope struct {
+		Version Version `json:"version"`
+	}
+	var e envelope
+	if err := json.Unmarshal([]byte(`{"version":"v1.stable.0.stable.0.stable"}`), &e); err != nil {
+		t.Fatal(err)
+	}
+	if e.Version != MustParseVersion("v1.stable.0.stable.0.stable") {
+		t.Errorf("decoded %+v", e.Version)
+	}
+	b, err := json.Marshal(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `{"version":"v1.stable.0.stable.0.stable"}` {
+		t.Errorf("encoded %s", b)
+	}
+
+	b, _ = json.Marshal(envelope{})
+	if string(b) != `{"version":null}` {
+		t.Errorf("zero version encoded as %s", b)
+	}
+	e = envelope{}
+	if err := json.Unmarshal([]byte(`{"version":null}`), &e); err != nil || !e.Version.IsZero() {
+		t.Errorf("null: %+v, %v", e.Version, err)
+	}
+}
+
+func TestVersionJSONErrors(t *testing.T) {
+	for _, in := range []string{
+		`{"version":""}`,
+		`{"version":"1.0.0"}`,
+		`{"version":"v1.stable.0.nightly.0.stable"}`,
+		`{"version":1}`,
+		`{"version":{"major":1}}`,
+	} {
+		var e struct {
+			Version Version `json:"version"`
+		}
+		err := json.Unmarshal([]byte(in), &e)
+		if err == nil || !strings.Contains(err.Error(), "semverx") {
+			t.Errorf("Unmarshal(%s) err = %v, want a descriptive semverx error", in, err)
+		}
+	}
+}
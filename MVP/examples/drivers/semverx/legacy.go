@@ -0,0 +1,74 @@
+package semverx
+
+import "strings"
+
+// LegacyMigration upgrades classic three-number versions such as "v1.0.0",
+// still reported by older peers, to SemVerX.
+type LegacyMigration struct {
+	// Channel is given to every component of a migrated version.
+	// ChannelUnknown means ChannelStable.
+	Channel Channel
+}
+
+// DefaultLegacyMigration is used by MigrateLegacy.
+var DefaultLegacyMigration = LegacyMigration{Channel: ChannelStable}
+
+// MigrateLegacy upgrades raw under DefaultLegacyMigration, so "v2.3.4"
+// becomes v2.stable.3.stable.4.stable.
+func MigrateLegacy(raw string) (Version, error) {
+	return DefaultLegacyMigration.Migrate(raw)
+}
+
+// Migrate converts a classic version of three decimal numbers, with an
+// optional 'v' prefix, into a Version with m.Channel on every component.
+// Input that cannot be mapped unambiguously is an error: a different
+// number of parts, leading zeros, and pre-release or build suffixes such
+// as "1.0.0-beta.1", whose channel would have to be guessed.
+func (m LegacyMigration) Migrate(raw string) (Version, error) {
+	if raw == "" {
+		return Version{}, ErrEmptyVersion
+	}
+	if strings.ContainsAny(raw, "-+") {
+		return Version{}, errorf(ErrMalformed, "semverx: legacy version %q has a pre-release or build suffix", raw)
+	}
+	parts := strings.Split(strings.TrimPrefix(raw, "v"), ".")
+	if len(parts) != 3 {
+		return Version{}, errorf(ErrMalformed, "semverx: legacy version %q must have 3 dot-separated numbers, got %d parts", raw, len(parts))
+	}
+	var nums [3]int
+	for i, p := range parts {
+		if len(p) > 1 && p[0] == '0' {
+			return Version{}, errorf(ErrBadComponent, "semverx: legacy version %q: leading zero in %q", raw, p)
+		}
+		n, err := parseComponent(p)
+		if err != nil {
+			return Version{}, errorf(ErrBadComponent, "semverx: legacy version %q: %v", raw, err)
+		}
+		nums[i] = n
+	}
+	c := m.Channel
+	if c == ChannelUnknown {
+		c = ChannelStable
+	}
+	return Version{
+		Major: nums[0], MajorChannel: c,
+		Minor: nums[1], MinorChannel: c,
+		Patch: nums[2], PatchChannel: c,
+	}, nil
+}
+
+// Parse is ParseVersion falling back to Migrate for input of the legacy
+// three-number shape, for use where peers on both schemes are expected.
+func (m LegacyMigration) Parse(s string) (Version, error) {
+	v, err := ParseVersion(s)
+	if err == nil || !isLegacyShape(s) {
+		return v, err
+	}
+	return m.Migrate(s)
+}
+
+// isLegacyShape reports whether s has three dot-separated parts and so is
+// meant as a classic version rather than a malformed SemVerX one.
+func isLegacyShape(s string) bool {
+	return strings.Count(s, ".") == 2
+}
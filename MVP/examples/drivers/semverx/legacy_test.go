@@ -0,0 +1,56 @@
+package semverx
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMigrateLegacy(t *testing.T) {
+	for raw, want := range map[string]string{
+		"v2.3.4":  "v2.stable.3.stable.4.stable",
+		"1.0.0":   "v1.stable.0.stable.0.stable",
+		"v10.0.7": "v10.stable.0.stable.7.stable",
+	} {
+		v, err := MigrateLegacy(raw)
+		if err != nil || v.String() != want {
+			t.Errorf("MigrateLegacy(%q) = %s, %v, want %s", raw, v, err, want)
+		}
+	}
+
+	v, err := LegacyMigration{Channel: ChannelBeta}.Migrate("v2.3.4")
+	if err != nil || v.String() != "v2.beta.3.beta.4.beta" {
+		t.Errorf("Migrate with beta = %s, %v", v, err)
+	}
+
+	for raw, kind := range map[string]error{
+		"":                            ErrEmptyVersion,
+		"v1.0":                        ErrMalformed,
+		"v1.0.0.0":                    ErrMalformed,
+		"v1.0.0-beta.1":               ErrMalformed,
+		"v1.0.0+build5":               ErrMalformed,
+		"v1.stable.0.stable.0.stable": ErrMalformed,
+		"v01.0.0":                     ErrBadComponent,
+		"v1.x.0":                      ErrBadComponent,
+		"v1..0":                       ErrBadComponent,
+	} {
+		if _, err := MigrateLegacy(raw); !errors.Is(err, kind) {
+			t.Errorf("MigrateLegacy(%q) = %v, want errors.Is %v", raw, err, kind)
+		}
+	}
+}
+
+func TestLegacyMigrationParse(t *testing.T) {
+	m := DefaultLegacyMigration
+	for raw, want := range map[string]string{
+		"v2.3.4":                    "v2.stable.3.stable.4.stable",
+		"v1.stable.2.beta.0.stable": "v1.stable.2.beta.0.stable",
+		"1.stable.2.beta2.0.stable": "v1.stable.2.beta2.0.stable",
+	} {
+		if v, err := m.Parse(raw); err != nil || v.String() != want {
+			t.Errorf("Parse(%q) = %s, %v, want %s", raw, v, err, want)
+		}
+	}
+	if _, err := m.Parse("v1.nightly.0.stable.0.stable"); !errors.Is(err, ErrUnknownChannel) {
+		t.Errorf("Parse(unknown channel) = %v, want the ParseVersion error", err)
+	}
+}
@@ -0,0 +1,54 @@
+package semverx
+
+// CompatibilityMatrix returns the grid m where m[i][j] reports whether a
+// node on versions[i] accepts a peer on versions[j], that is
+// Compatible(versions[i], versions[j]). Compatibility is not symmetric, so
+// the grid need not be either.
+func CompatibilityMatrix(versions []Version) [][]bool {
+	m := make([][]bool, len(versions))
+	for i, local := range versions {
+		m[i] = make([]bool, len(versions))
+		for j, remote := range versions {
+			m[i][j] = Compatible(local, remote)
+		}
+	}
+	return m
+}
+
+// LargestCompatibleSet returns the indices, in ascending order, of the
+// largest subset of versions in which every pair is compatible in both
+// directions. Among subsets of equal size the one with the lowest indices
+// wins. It returns nil for an empty input.
+//
+// The search is exponential in the worst case, which is fine for the mesh
+// sizes this is meant for.
+func LargestCompatibleSet(versions []Version) []int {
+	m := CompatibilityMatrix(versions)
+	mutual := func(i, j int) bool { return m[i][j] && m[j][i] }
+
+	var best []int
+	var grow func(set, candidates []int)
+	grow = func(set, candidates []int) {
+		if len(set) > len(best) {
+			best = append([]int(nil), set...)
+		}
+		for k, c := range candidates {
+			if len(set)+len(candidates)-k <= len(best) {
+				return
+			}
+			var next []int
+			for _, d := range candidates[k+1:] {
+				if mutual(c, d) {
+					next = append(next, d)
+				}
+			}
+			grow(append(set, c), next)
+		}
+	}
+	all := make([]int, len(versions))
+	for i := range all {
+		all[i] = i
+	}
+	grow(nil, all)
+	return best
+}
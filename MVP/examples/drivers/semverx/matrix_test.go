@@ -0,0 +1,75 @@
+package semverx
+
+import (
+	"reflect"
+	"testing"
+)
+
+func parseAll(t *testing.T, ss ...string) []Version {
+	t.Helper()
+	vs := make([]Version, len(ss))
+	for i, s := range ss {
+		vs[i] = MustParseVersion(s)
+	}
+	return vs
+}
+
+func TestCompatibilityMatrixAllCompatible(t *testing.T) {
+	vs := parseAll(t, "v1.stable.0.stable.0.stable", "v1.stable.1.stable.0.stable", "v1.stable.2.stable.5.stable")
+	m := CompatibilityMatrix(vs)
+	for i := range m {
+		for j := range m[i] {
+			if !m[i][j] {
+				t.Errorf("m[%d][%d] = false", i, j)
+			}
+		}
+	}
+	if got := LargestCompatibleSet(vs); !reflect.DeepEqual(got, []int{0, 1, 2}) {
+		t.Errorf("LargestCompatibleSet = %v", got)
+	}
+}
+
+func TestCompatibilityMatrixNoneCompatible(t *testing.T) {
+	vs := parseAll(t, "v1.stable.0.stable.0.stable", "v2.stable.0.stable.0.stable", "v3.stable.0.stable.0.stable")
+	m := CompatibilityMatrix(vs)
+	for i := range m {
+		for j := range m[i] {
+			if m[i][j] != (i == j) {
+				t.Errorf("m[%d][%d] = %v", i, j, m[i][j])
+			}
+		}
+	}
+	if got := LargestCompatibleSet(vs); !reflect.DeepEqual(got, []int{0}) {
+		t.Errorf("LargestCompatibleSet = %v, want [0]", got)
+	}
+}
+
+func TestCompatibilityMatrixAsymmetric(t *testing.T) {
+	vs := parseAll(t, "v1.beta.0.stable.0.stable", "v1.stable.0.stable.0.stable")
+	m := CompatibilityMatrix(vs)
+	if want := [][]bool{{true, true}, {false, true}}; !reflect.DeepEqual(m, want) {
+		t.Errorf("matrix = %v, want %v", m, want)
+	}
+	if got := LargestCompatibleSet(vs); len(got) != 1 {
+		t.Errorf("LargestCompatibleSet = %v, want a single node", got)
+	}
+}
+
+func TestLargestCompatibleSet(t *testing.T) {
+	vs := parseAll(t,
+		"v1.stable.0.stable.0.stable",
+		"v2.stable.0.stable.0.stable",
+		"v1.stable.4.stable.0.stable",
+		"v1.stable.2.stable.0.stable",
+		"v1.stable.3.stable.0.stable",
+		"v1.stable.5.stable.0.stable",
+	)
+	// Minor skew of at most 2: {2,3,4} and {2,4,5} both have three members;
+	// the lower indices win.
+	if got := LargestCompatibleSet(vs); !reflect.DeepEqual(got, []int{2, 3, 4}) {
+		t.Errorf("LargestCompatibleSet = %v, want [2 3 4]", got)
+	}
+	if got := LargestCompatibleSet(nil); got != nil {
+		t.Errorf("LargestCompatibleSet(nil) = %v", got)
+	}
+}
@@ -0,0 +1,222 @@
+package semverx
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// UpgradePlanner works out how a set of services can reach a set of
+// desired constraints while staying mutually compatible.
+type UpgradePlanner struct {
+	// Current maps every service in the set to the version it runs now.
+	Current map[string]Version
+	// Available lists the versions each service could be moved to.
+	Available map[string][]Version
+	// AllowChannelDowngrade lets the planner pick a version with any
+	// component on a less stable channel than the service runs now.
+	AllowChannelDowngrade bool
+}
+
+// UpgradeStep moves one service to a new version.
+type UpgradeStep struct {
+	ServiceID string
+	From, To  Version
+	Diff      VersionDiff
+}
+
+// UpgradePlan is the outcome of a successful dry run.
+type UpgradePlan struct {
+	// Steps lists the services that change, least disruptive first:
+	// channel-only, then patch, minor and major changes, with service ID
+	// breaking ties.
+	Steps []UpgradeStep
+	// Final is the version of every service once all steps are applied.
+	Final map[string]Version
+}
+
+// PlanConflict explains why part of a plan cannot be satisfied.
+type PlanConflict struct {
+	Services []string
+	Reason   string
+}
+
+// PlanError is returned by Plan when no plan exists.
+type PlanError struct {
+	Conflicts []PlanConflict
+}
+
+func (e *PlanError) Error() string {
+	parts := make([]string, len(e.Conflicts))
+	for i, c := range e.Conflicts {
+		parts[i] = strings.Join(c.Services, ", ") + ": " + c.Reason
+	}
+	return "semverx: no upgrade plan: " + strings.Join(parts, "; ")
+}
+
+// Plan picks a version for every service such that each service with a
+// desired constraint satisfies it and every pair of services is compatible
+// in both directions. Services without a constraint may also be moved when
+// that is needed to keep the set compatible.
+//
+// A service never moves to a lower version, nor, unless
+// AllowChannelDowngrade is set, to one with a less stable channel in any
+// component. Among the remaining candidates the planner prefers keeping
+// the current version and otherwise the smallest upgrade. When no plan
+// exists the error is a *PlanError.
+func (p UpgradePlanner) Plan(desired map[string]Constraint) (UpgradePlan, error) {
+	var conflicts []PlanConflict
+	var unknown []string
+	for id := range desired {
+		if _, ok := p.Current[id]; !ok {
+			unknown = append(unknown, id)
+		}
+	}
+	sort.Strings(unknown)
+	for _, id := range unknown {
+		conflicts = append(conflicts, PlanConflict{[]string{id}, "not in the current service set"})
+	}
+
+	ids := make([]string, 0, len(p.Current))
+	for id := range p.Current {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	candidates := make([][]Version, len(ids))
+	for i, id := range ids {
+		c, constrained := desired[id]
+		candidates[i] = p.candidates(id, c, constrained)
+		if len(candidates[i]) == 0 {
+			conflicts = append(conflicts, PlanConflict{[]string{id}, p.noCandidateReason(id, c)})
+		}
+	}
+	if len(conflicts) > 0 {
+		return UpgradePlan{}, &PlanError{conflicts}
+	}
+
+	chosen := make([]Version, len(ids))
+	if !assign(candidates, chosen, 0) {
+		return UpgradePlan{}, &PlanError{incompatibleSets(ids, candidates)}
+	}
+
+	plan := UpgradePlan{Final: make(map[string]Version, len(ids))}
+	for i, id := range ids {
+		plan.Final[id] = chosen[i]
+		if from := p.Current[id]; !from.Equal(chosen[i]) {
+			plan.Steps = append(plan.Steps, UpgradeStep{ServiceID: id, From: from, To: chosen[i], Diff: Diff(from, chosen[i])})
+		}
+	}
+	sort.SliceStable(plan.Steps, func(i, j int) bool {
+		return diffKindOrder[plan.Steps[i].Diff.Kind] < diffKindOrder[plan.Steps[j].Diff.Kind]
+	})
+	return plan, nil
+}
+
+var diffKindOrder = map[DiffKind]int{DiffNone: 0, DiffChannelOnly: 1, DiffPatch: 2, DiffMinor: 3, DiffMajor: 4}
+
+// candidates returns the versions id may end up on, in order of
+// preference: the current version first, then upgrades from lowest to
+// highest.
+func (p UpgradePlanner) candidates(id string, c Constraint, constrained bool) []Version {
+	cur := p.Current[id]
+	var out []Version
+	if !constrained || c.Matches(cur) {
+		out = append(out, cur)
+	}
+	ups := append([]Version(nil), p.Available[id]...)
+	sort.Slice(ups, func(i, j int) bool { return ups[i].Less(ups[j]) })
+	for i, v := range ups {
+		if i > 0 && v.Equal(ups[i-1]) || !cur.Less(v) {
+			continue
+		}
+		if !p.AllowChannelDowngrade && !meetsChannelFloor(v, cur) {
+			continue
+		}
+		if constrained && !c.Matches(v) {
+			continue
+		}
+		out = append(out, v)
+	}
+	return out
+}
+
+// noCandidateReason explains why id has no candidate under c.
+func (p UpgradePlanner) noCandidateReason(id string, c Constraint) string {
+	cur := p.Current[id]
+	for _, v := range p.Available[id] {
+		if c.Matches(v) && cur.Less(v) {
+			return fmt.Sprintf("every version satisfying %q would lower a channel of %s", c, cur)
+		}
+	}
+	for _, v := range p.Available[id] {
+		if c.Matches(v) {
+			return fmt.Sprintf("every version satisfying %q is older than %s", c, cur)
+		}
+	}
+	return fmt.Sprintf("no available version satisfies %q", c)
+}
+
+// assign fills chosen[i:] by backtracking so that every chosen version is
+// mutually compatible with all earlier ones.
+func assign(candidates [][]Version, chosen []Version, i int) bool {
+	if i == len(candidates) {
+		return true
+	}
+next:
+	for _, v := range candidates[i] {
+		for _, prev := range chosen[:i] {
+			if !mutuallyCompatible(v, prev) {
+				continue next
+			}
+		}
+		chosen[i] = v
+		if assign(candidates, chosen, i+1) {
+			return true
+		}
+	}
+	return false
+}
+
+func mutuallyCompatible(a, b Version) bool {
+	return Compatible(a, b) && Compatible(b, a)
+}
+
+// incompatibleSets names the pairs of services that have no mutually
+// compatible choice. When every pair could be satisfied on its own, the
+// conflict spans the whole set.
+func incompatibleSets(ids []string, candidates [][]Version) []PlanConflict {
+	var out []PlanConflict
+	for i := range ids {
+		for j := i + 1; j < len(ids); j++ {
+			if !anyPairCompatible(candidates[i], candidates[j]) {
+				out = append(out, PlanConflict{
+					Services: []string{ids[i], ids[j]},
+					Reason:   fmt.Sprintf("no compatible pair among %s and %s", versionList(candidates[i]), versionList(candidates[j])),
+				})
+			}
+		}
+	}
+	if len(out) == 0 {
+		out = append(out, PlanConflict{ids, "candidate versions are pairwise compatible but no single combination is compatible across all services"})
+	}
+	return out
+}
+
+func anyPairCompatible(as, bs []Version) bool {
+	for _, a := range as {
+		for _, b := range bs {
+			if mutuallyCompatible(a, b) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func versionList(vs []Version) string {
+	s := make([]string, len(vs))
+	for i, v := range vs {
+		s[i] = v.String()
+	}
+	return "[" + strings.Join(s, " ") + "]"
+}
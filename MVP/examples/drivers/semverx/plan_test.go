@@ -0,0 +1,150 @@
+package semverx
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func mustConstraint(t *testing.T, s string) Constraint {
+	t.Helper()
+	c, err := ParseConstraint(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestPlanUpgrades(t *testing.T) {
+	p := UpgradePlanner{
+		Current: map[string]Version{
+			"api":    MustParseVersion("v1.stable.0.stable.0.stable"),
+			"worker": MustParseVersion("v1.stable.0.stable.0.stable"),
+			"web":    MustParseVersion("v1.stable.1.stable.0.stable"),
+		},
+		Available: map[string][]Version{
+			"api":    parseAll(t, "v1.stable.4.stable.0.stable", "v1.stable.3.stable.0.stable", "v1.stable.3.stable.1.stable"),
+			"worker": parseAll(t, "v1.stable.2.stable.0.stable", "v1.stable.3.stable.0.stable"),
+			"web":    parseAll(t, "v1.stable.1.stable.2.stable"),
+		},
+	}
+	plan, err := p.Plan(map[string]Constraint{
+		"api": mustConstraint(t, ">=v1.stable.3.stable.0.stable"),
+		"web": mustConstraint(t, "v1.stable.1.stable.*.stable"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// api moves to the smallest satisfying upgrade; worker must follow to
+	// stay within the minor skew of 2, web already satisfies its constraint.
+	want := []struct{ id, to string }{
+		{"api", "v1.stable.3.stable.0.stable"},
+		{"worker", "v1.stable.2.stable.0.stable"},
+	}
+	if len(plan.Steps) != len(want) {
+		t.Fatalf("steps = %+v", plan.Steps)
+	}
+	for i, w := range want {
+		if s := plan.Steps[i]; s.ServiceID != w.id || s.To.String() != w.to {
+			t.Errorf("step %d = %s -> %s, want %s -> %s", i, s.ServiceID, s.To, w.id, w.to)
+		}
+	}
+	if plan.Final["web"].String() != "v1.stable.1.stable.0.stable" {
+		t.Errorf("web final = %s", plan.Final["web"])
+	}
+}
+
+func TestPlanStepOrder(t *testing.T) {
+	p := UpgradePlanner{
+		Current: map[string]Version{
+			"a": MustParseVersion("v1.stable.0.stable.0.stable"),
+			"b": MustParseVersion("v1.stable.0.stable.0.stable"),
+		},
+		Available: map[string][]Version{
+			"a": parseAll(t, "v1.stable.1.stable.0.stable"),
+			"b": parseAll(t, "v1.stable.0.stable.1.stable"),
+		},
+	}
+	plan, err := p.Plan(map[string]Constraint{
+		"a": mustConstraint(t, "v1.stable.1.stable.0.stable"),
+		"b": mustConstraint(t, "v1.stable.0.stable.1.stable"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Steps) != 2 || plan.Steps[0].ServiceID != "b" || plan.Steps[0].Diff.Kind != DiffPatch || plan.Steps[1].Diff.Kind != DiffMinor {
+		t.Errorf("steps = %+v, want the patch step before the minor one", plan.Steps)
+	}
+}
+
+func TestPlanChannelDowngrade(t *testing.T) {
+	p := UpgradePlanner{
+		Current:   map[string]Version{"api": MustParseVersion("v1.stable.0.stable.0.stable")},
+		Available: map[string][]Version{"api": parseAll(t, "v1.stable.1.beta.0.stable")},
+	}
+	desired := map[string]Constraint{"api": mustConstraint(t, ">v1.stable.0.stable.0.stable")}
+	_, err := p.Plan(desired)
+	var pe *PlanError
+	if !errors.As(err, &pe) || len(pe.Conflicts) != 1 || !strings.Contains(pe.Conflicts[0].Reason, "lower a channel") {
+		t.Fatalf("err = %v, want a channel conflict", err)
+	}
+
+	p.AllowChannelDowngrade = true
+	plan, err := p.Plan(desired)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Steps) != 1 || plan.Steps[0].To.String() != "v1.stable.1.beta.0.stable" {
+		t.Errorf("steps = %+v", plan.Steps)
+	}
+}
+
+func TestPlanConflicts(t *testing.T) {
+	p := UpgradePlanner{
+		Current: map[string]Version{
+			"api":    MustParseVersion("v1.stable.0.stable.0.stable"),
+			"worker": MustParseVersion("v1.stable.0.stable.0.stable"),
+			"legacy": MustParseVersion("v1.stable.5.stable.0.stable"),
+		},
+		Available: map[string][]Version{
+			"api": parseAll(t, "v2.stable.0.stable.0.stable", "v0.stable.9.stable.0.stable"),
+		},
+	}
+
+	_, err := p.Plan(map[string]Constraint{
+		"ghost": mustConstraint(t, "v1.x"),
+		"api":   mustConstraint(t, "<v1.stable.0.stable.0.stable"),
+	})
+	var pe *PlanError
+	if !errors.As(err, &pe) || len(pe.Conflicts) != 2 {
+		t.Fatalf("err = %v", err)
+	}
+	if pe.Conflicts[0].Services[0] != "ghost" || !strings.Contains(pe.Conflicts[1].Reason, "older than") {
+		t.Errorf("conflicts = %+v", pe.Conflicts)
+	}
+
+	// api can only reach v2, which nobody else can follow. worker and
+	// legacy are also too far apart in minor version to coexist.
+	_, err = p.Plan(map[string]Constraint{"api": mustConstraint(t, "^v2.stable.0.stable.0.stable")})
+	if !errors.As(err, &pe) {
+		t.Fatalf("err = %v", err)
+	}
+	var pairs []string
+	for _, c := range pe.Conflicts {
+		pairs = append(pairs, strings.Join(c.Services, "+"))
+	}
+	if got := strings.Join(pairs, " "); got != "api+legacy api+worker legacy+worker" {
+		t.Errorf("conflicting pairs = %s", got)
+	}
+	if !strings.Contains(err.Error(), "legacy, worker: no compatible pair") {
+		t.Errorf("Error() = %s", err)
+	}
+}
+
+func TestPlanNoChange(t *testing.T) {
+	p := UpgradePlanner{Current: map[string]Version{"api": MustParseVersion("v1.stable.0.stable.0.stable")}}
+	plan, err := p.Plan(nil)
+	if err != nil || len(plan.Steps) != 0 || len(plan.Final) != 1 {
+		t.Errorf("Plan(nil) = %+v, %v", plan, err)
+	}
+}
@@ -0,0 +1,93 @@
+package semverx
+
+import (
+	"fmt"
+	"strings"
+)
+
+// StabilityPolicy sets a floor on how unstable a version may be.
+type StabilityPolicy struct {
+	// MinChannel is the least stable channel any component may carry.
+	// ChannelUnknown disables the policy.
+	MinChannel Channel
+}
+
+// StabilityViolation is returned by StabilityPolicy.Check.
+type StabilityViolation struct {
+	Version    Version
+	Component  string // "major", "minor" or "patch"
+	Channel    Channel
+	MinChannel Channel
+}
+
+func (e *StabilityViolation) Error() string {
+	return fmt.Sprintf("semverx: %s component of %s is %s, below the minimum stability %s",
+		e.Component, e.Version, e.Channel, e.MinChannel)
+}
+
+// Check returns a *StabilityViolation naming the least stable component of
+// v when it ranks below p.MinChannel. When several components tie, the most
+// significant one is reported.
+func (p StabilityPolicy) Check(v Version) error {
+	if p.MinChannel == ChannelUnknown {
+		return nil
+	}
+	name, low := v.lowestComponent()
+	if low.Rank() >= p.MinChannel.Rank() {
+		return nil
+	}
+	return &StabilityViolation{Version: v, Component: name, Channel: low, MinChannel: p.MinChannel}
+}
+
+// componentNames names the components of a version, most significant first.
+var componentNames = [3]string{"major", "minor", "patch"}
+
+// IsPrerelease reports whether any component's channel ranks below stable.
+// Legacy and unrecognized channels count, since neither promises stability.
+func (v Version) IsPrerelease() bool {
+	return v.LowestChannel().Rank() < ChannelStable.Rank()
+}
+
+// LowestChannel returns the least stable channel across the components of
+// v.
+func (v Version) LowestChannel() Channel {
+	_, c := v.lowestComponent()
+	return c
+}
+
+// lowestComponent returns the least stable component of v and its
+// channel. When several components tie, the most significant one wins.
+func (v Version) lowestComponent() (string, Channel) {
+	chans := [3]Channel{v.MajorChannel, v.MinorChannel, v.PatchChannel}
+	low := 0
+	for i, c := range chans {
+		if c.Rank() < chans[low].Rank() {
+			low = i
+		}
+	}
+	return componentNames[low], chans[low]
+}
+
+// StabilitySummary describes v for humans: "stable", or "prerelease"
+// followed by each component below stable, e.g. "prerelease (beta minor)"
+// for v1.stable.2.beta.0.stable.
+func (v Version) StabilitySummary() string {
+	if !v.IsPrerelease() {
+		return "stable"
+	}
+	chans := [3]Channel{v.MajorChannel, v.MinorChannel, v.PatchChannel}
+	iters := [3]int{v.MajorIteration, v.MinorIteration, v.PatchIteration}
+	raw := v.rawChannels()
+	var parts []string
+	for i, c := range chans {
+		if c.Rank() >= ChannelStable.Rank() {
+			continue
+		}
+		tok := formatChannel(c, iters[i])
+		if c == ChannelUnknown && raw[i] != "" {
+			tok = raw[i]
+		}
+		parts = append(parts, tok+" "+componentNames[i])
+	}
+	return "prerelease (" + strings.Join(parts, ", ") + ")"
+}
@@ -0,0 +1,78 @@
+package semverx
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestStabilityPolicy(t *testing.T) {
+	rc := StabilityPolicy{MinChannel: ChannelRC}
+	tests := []struct {
+		policy    StabilityPolicy
+		version   string
+		component string // empty when the version passes
+	}{
+		{rc, "v1.stable.0.stable.0.stable", ""},
+		{rc, "v1.rc.0.rc.0.rc", ""},
+		{rc, "v1.stable.0.beta.0.stable", "minor"},
+		{rc, "v1.stable.0.stable.0.alpha", "patch"},
+		{rc, "v1.beta.0.stable.0.alpha", "patch"},
+		{rc, "v1.beta.0.beta.0.stable", "major"},
+		{rc, "v1.stable.0.stable.0.legacy", "patch"},
+		{StabilityPolicy{MinChannel: ChannelStable}, "v1.stable.0.rc.0.stable", "minor"},
+		{StabilityPolicy{MinChannel: ChannelAlpha}, "v1.alpha.0.alpha.0.alpha", ""},
+		{StabilityPolicy{MinChannel: ChannelAlpha}, "v1.alpha.0.experimental.0.alpha", "minor"},
+		{StabilityPolicy{}, "v1.experimental.0.legacy.0.alpha", ""},
+	}
+	for _, tt := range tests {
+		err := tt.policy.Check(MustParseVersion(tt.version))
+		if tt.component == "" {
+			if err != nil {
+				t.Errorf("floor %v, %s: unexpected %v", tt.policy.MinChannel, tt.version, err)
+			}
+			continue
+		}
+		var sv *StabilityViolation
+		if !errors.As(err, &sv) {
+			t.Errorf("floor %v, %s: err = %v, want a violation", tt.policy.MinChannel, tt.version, err)
+			continue
+		}
+		if sv.Component != tt.component || sv.MinChannel != tt.policy.MinChannel {
+			t.Errorf("floor %v, %s: violation = %+v, want component %s", tt.policy.MinChannel, tt.version, sv, tt.component)
+		}
+	}
+}
+
+func TestStabilityHelpers(t *testing.T) {
+	for _, tt := range []struct {
+		version    string
+		prerelease bool
+		lowest     Channel
+		summary    string
+	}{
+		{"v1.stable.2.stable.0.stable", false, ChannelStable, "stable"},
+		{"v1.beta.2.beta.0.beta", true, ChannelBeta, "prerelease (beta major, beta minor, beta patch)"},
+		{"v1.stable.2.beta.0.stable", true, ChannelBeta, "prerelease (beta minor)"},
+		{"v1.stable.2.rc.0.alpha3", true, ChannelAlpha, "prerelease (rc minor, alpha3 patch)"},
+		{"v1.legacy.0.stable.0.stable", true, ChannelLegacy, "prerelease (legacy major)"},
+	} {
+		v := MustParseVersion(tt.version)
+		if got := v.IsPrerelease(); got != tt.prerelease {
+			t.Errorf("%s.IsPrerelease() = %v", tt.version, got)
+		}
+		if got := v.LowestChannel(); got != tt.lowest {
+			t.Errorf("%s.LowestChannel() = %s, want %s", tt.version, got, tt.lowest)
+		}
+		if got := v.StabilitySummary(); got != tt.summary {
+			t.Errorf("%s.StabilitySummary() = %q, want %q", tt.version, got, tt.summary)
+		}
+	}
+
+	v, err := ParseLenient("v1.stable.2.nightly.0.stable")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !v.IsPrerelease() || v.LowestChannel() != ChannelUnknown || v.StabilitySummary() != "prerelease (nightly minor)" {
+		t.Errorf("degraded %s: %v, %s, %q", v, v.IsPrerelease(), v.LowestChannel(), v.StabilitySummary())
+	}
+}
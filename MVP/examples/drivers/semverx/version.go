@@ -0,0 +1,243 @@
+// Package semverx implements the SemVerX version format used by the P2P
+// drivers: v<MAJOR>.<channel>.<MINOR>.<channel>.<PATCH>.<channel>.
+package semverx
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version is a parsed SemVerX version. Every numeric component carries its
+// own stability channel, optionally followed by an iteration number that
+// orders releases within the same channel (beta1 < beta2).
+type Version struct {
+	Major          int
+	MajorChannel   Channel
+	MajorIteration int
+	Minor          int
+	MinorChannel   Channel
+	MinorIteration int
+	Patch          int
+	PatchChannel   Channel
+	PatchIteration int
+
+	// Degraded is set by ParseLenient when some channel tokens were not
+	// recognized. Those components carry ChannelUnknown and Raw keeps the
+	// original string.
+	Degraded bool
+	Raw      string
+}
+
+// ParseVersion decodes a string such as "v1.stable.0.stable.0.stable".
+// The 'v' prefix is optional, so "1.stable.0.stable.0.stable" parses to the
+// same version; String always adds it back. Use ParseVersionStrict where
+// the prefix is required.
+//
+// A channel token may carry a numeric iteration suffix, as in
+// "v1.stable.2.beta3.0.stable"; a token without one has iteration 0, so
+// "beta" and "beta0" denote the same channel position.
+func ParseVersion(s string) (Version, error) {
+	return parseVersion(s, false, false)
+}
+
+// ParseVersionStrict is like ParseVersion but rejects versions without the
+// 'v' prefix.
+func ParseVersionStrict(s string) (Version, error) {
+	return parseVersion(s, true, false)
+}
+
+// ParseLenient is like ParseVersion but accepts channel tokens it does not
+// know, such as a channel introduced by a newer release, so a peer's
+// numbers remain usable. Such components get ChannelUnknown, which ranks
+// below every known channel, and the result is Degraded with s kept in Raw;
+// String returns s, with the 'v' prefix added if it was missing. Malformed
+// structure or numbers are still errors, and input ParseVersion accepts
+// yields the same Version.
+func ParseLenient(s string) (Version, error) {
+	return parseVersion(s, false, true)
+}
+
+func parseVersion(s string, strict, lenient bool) (Version, error) {
+	var v Version
+	if s == "" {
+		return v, ErrEmptyVersion
+	}
+	body, hasPrefix := strings.CutPrefix(s, "v")
+	if strict && !hasPrefix {
+		return v, errorf(ErrMissingPrefix, "semverx: version %q is missing the 'v' prefix", s)
+	}
+	parts := strings.Split(body, ".")
+	if len(parts) != 6 {
+		return v, errorf(ErrMalformed, "semverx: version %q must have 6 dot-separated parts, got %d", s, len(parts))
+	}
+
+	nums := [3]*int{&v.Major, &v.Minor, &v.Patch}
+	chans := [3]*Channel{&v.MajorChannel, &v.MinorChannel, &v.PatchChannel}
+	iters := [3]*int{&v.MajorIteration, &v.MinorIteration, &v.PatchIteration}
+	for i := 0; i < 3; i++ {
+		n, err := parseComponent(parts[2*i])
+		if err != nil {
+			return Version{}, fmt.Errorf("semverx: version %q: %w", s, err)
+		}
+		c, it, err := parseChannelIteration(parts[2*i+1])
+		if err != nil {
+			if !lenient || parts[2*i+1] == "" {
+				return Version{}, fmt.Errorf("semverx: version %q: %w", s, err)
+			}
+			c, it, v.Degraded = ChannelUnknown, 0, true
+		}
+		*nums[i], *chans[i], *iters[i] = n, c, it
+	}
+	if v.Degraded {
+		v.Raw = s
+	}
+	return v, nil
+}
+
+// UnknownTokens returns the channel tokens of Raw that ParseLenient did not
+// recognize, in component order. It is nil unless v is Degraded.
+func (v Version) UnknownTokens() []string {
+	var out []string
+	for i, tok := range v.rawChannels() {
+		if tok != "" && [...]Channel{v.MajorChannel, v.MinorChannel, v.PatchChannel}[i] == ChannelUnknown {
+			out = append(out, tok)
+		}
+	}
+	return out
+}
+
+// rawChannels returns the channel tokens of Raw, or empty strings when v
+// is not Degraded.
+func (v Version) rawChannels() [3]string {
+	var toks [3]string
+	if !v.Degraded {
+		return toks
+	}
+	parts := strings.Split(strings.TrimPrefix(v.Raw, "v"), ".")
+	if len(parts) == 6 {
+		toks = [3]string{parts[1], parts[3], parts[5]}
+	}
+	return toks
+}
+
+// MustParseVersion is like ParseVersion but panics on error. It is intended
+// for package-level constants.
+func MustParseVersion(s string) Version {
+	v, err := ParseVersion(s)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+func parseComponent(s string) (int, error) {
+	if s == "" || s[0] < '0' || s[0] > '9' {
+		return 0, errorf(ErrBadComponent, "invalid numeric component %q", s)
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, errorf(ErrBadComponent, "invalid numeric component %q", s)
+	}
+	return n, nil
+}
+
+// parseChannelIteration splits a token such as "beta2" into its channel and
+// iteration.
+func parseChannelIteration(tok string) (Channel, int, error) {
+	i := len(tok)
+	for i > 0 && tok[i-1] >= '0' && tok[i-1] <= '9' {
+		i--
+	}
+	c, err := ParseChannel(tok[:i])
+	if err != nil {
+		return ChannelUnknown, 0, err
+	}
+	if i == len(tok) {
+		return c, 0, nil
+	}
+	it, err := strconv.Atoi(tok[i:])
+	if err != nil {
+		return ChannelUnknown, 0, errorf(ErrBadComponent, "invalid iteration in %q", tok)
+	}
+	return c, it, nil
+}
+
+func formatChannel(c Channel, iteration int) string {
+	if iteration == 0 {
+		return c.String()
+	}
+	return c.String() + strconv.Itoa(iteration)
+}
+
+// String returns the canonical form, e.g. "v1.stable.0.beta2.0.stable".
+// Zero iterations are omitted. A Degraded version returns Raw, which
+// keeps the tokens it could not parse, prefixed with 'v' like any other.
+func (v Version) String() string {
+	if v.Degraded {
+		return "v" + strings.TrimPrefix(v.Raw, "v")
+	}
+	return v.format([3]string{})
+}
+
+// format writes v, using the non-empty entries of channels in place of the
+// major, minor and patch channel tokens.
+func (v Version) format(channels [3]string) string {
+	toks := [3]string{
+		formatChannel(v.MajorChannel, v.MajorIteration),
+		formatChannel(v.MinorChannel, v.MinorIteration),
+		formatChannel(v.PatchChannel, v.PatchIteration),
+	}
+	for i, c := range channels {
+		if c != "" {
+			toks[i] = c
+		}
+	}
+	return fmt.Sprintf("v%d.%s.%d.%s.%d.%s", v.Major, toks[0], v.Minor, toks[1], v.Patch, toks[2])
+}
+
+// Canonical returns the normalized string form of v. Two versions are
+// Equal exactly when their canonical forms match. The rules are:
+//
+//   - numbers are written in decimal without leading zeros, so "v01" and
+//     "v1" normalize alike;
+//   - a zero iteration is omitted and others lose leading zeros, so "beta",
+//     "beta0" and "beta00" all become "beta", and "beta02" becomes "beta2";
+//   - channels outside the known set, which Compare ranks together with
+//     ChannelUnknown, are written as ChannelUnknown;
+//   - except that a Degraded version keeps its unrecognized tokens, so
+//     peers on different unknown channels are not taken for one another.
+//
+// For any version produced by ParseVersion this is the same as String.
+func (v Version) Canonical() string {
+	for _, c := range [...]*Channel{&v.MajorChannel, &v.MinorChannel, &v.PatchChannel} {
+		if !c.valid() {
+			*c = ChannelUnknown
+		}
+	}
+	return v.format(v.unknownChannels())
+}
+
+// unknownChannels returns, for each component whose channel is not a known
+// one, the token Raw has there. Other entries, and all of them when v is not
+// Degraded, are empty.
+func (v Version) unknownChannels() [3]string {
+	var unknown [3]string
+	chans := [...]Channel{v.MajorChannel, v.MinorChannel, v.PatchChannel}
+	for i, tok := range v.rawChannels() {
+		if !chans[i].valid() {
+			unknown[i] = tok
+		}
+	}
+	return unknown
+}
+
+// Normalize parses raw and returns its canonical form. Normalize is
+// idempotent: normalizing its own output returns that output unchanged.
+func Normalize(raw string) (string, error) {
+	v, err := ParseVersion(raw)
+	if err != nil {
+		return "", err
+	}
+	return v.Canonical(), nil
+}
@@ -0,0 +1,221 @@
+package semverx
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseVersionRoundTrip(t *testing.T) {
+	for _, s := range []string{
+		"v1.stable.0.stable.0.stable",
+		"v2.stable.0.experimental.3.legacy",
+		"v10.legacy.20.stable.300.experimental",
+		"v1.beta1.0.stable.0.stable",
+		"v1.stable.2.rc12.0.alpha3",
+	} {
+		v, err := ParseVersion(s)
+		if err != nil {
+			t.Fatalf("ParseVersion(%q): %v", s, err)
+		}
+		if got := v.String(); got != s {
+			t.Errorf("ParseVersion(%q).String() = %q", s, got)
+		}
+	}
+}
+
+func TestParseVersionFields(t *testing.T) {
+	v, err := ParseVersion("v2.stable.0.experimental.3.legacy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Version{
+		Major: 2, MajorChannel: ChannelStable,
+		Minor: 0, MinorChannel: ChannelExperimental,
+		Patch: 3, PatchChannel: ChannelLegacy,
+	}
+	if v != want {
+		t.Errorf("got %+v, want %+v", v, want)
+	}
+}
+
+func TestParseVersionErrors(t *testing.T) {
+	for _, s := range []string{
+		"",
+		"v",
+		"vv1.stable.0.stable.0.stable",
+		"1.stable.0.stable",
+		"v1.stable.0.stable",
+		"v1.stable.0.stable.0.stable.0",
+		"vX.stable.0.stable.0.stable",
+		"v1.stable.-1.stable.0.stable",
+		"v1.stable.+1.stable.0.stable",
+		"v1.stable..stable.0.stable",
+		"v1.stable.0.nightly.0.stable",
+		"v1.stable.0.2.0.stable",
+		"v1.stable.0.beta-1.0.stable",
+		"v1.stable.0.beta1x.0.stable",
+		"v1.stable.0.beta99999999999999999999.0.stable",
+	} {
+		if _, err := ParseVersion(s); err == nil {
+			t.Errorf("ParseVersion(%q) succeeded, want error", s)
+		}
+	}
+}
+
+func TestParseVersionIterations(t *testing.T) {
+	v, err := ParseVersion("v1.beta2.3.rc.4.alpha10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.MajorChannel != ChannelBeta || v.MajorIteration != 2 ||
+		v.MinorChannel != ChannelRC || v.MinorIteration != 0 ||
+		v.PatchChannel != ChannelAlpha || v.PatchIteration != 10 {
+		t.Errorf("got %+v", v)
+	}
+
+	// An explicit zero iteration is the same as none and is not printed.
+	implicit := MustParseVersion("v1.beta.0.stable.0.stable")
+	explicit := MustParseVersion("v1.beta0.0.stable.0.stable")
+	if implicit != explicit {
+		t.Errorf("%+v != %+v", implicit, explicit)
+	}
+	if got := explicit.String(); got != "v1.beta.0.stable.0.stable" {
+		t.Errorf("String() = %q", got)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	for raw, want := range map[string]string{
+		"v1.stable.0.stable.0.stable":      "v1.stable.0.stable.0.stable",
+		"v01.stable.00.stable.007.stable":  "v1.stable.0.stable.7.stable",
+		"v1.beta0.2.rc00.3.alpha02":        "v1.beta.2.rc.3.alpha2",
+		"v2.experimental1.0.legacy.0.beta": "v2.experimental1.0.legacy.0.beta",
+	} {
+		got, err := Normalize(raw)
+		if err != nil {
+			t.Fatalf("Normalize(%q): %v", raw, err)
+		}
+		if got != want {
+			t.Errorf("Normalize(%q) = %q, want %q", raw, got, want)
+		}
+		again, err := Normalize(got)
+		if err != nil || again != got {
+			t.Errorf("Normalize is not idempotent on %q: got %q, %v", got, again, err)
+		}
+	}
+	if _, err := Normalize("1.0.0"); err == nil {
+		t.Error("Normalize accepted an invalid version")
+	}
+}
+
+func TestEqualCanonical(t *testing.T) {
+	a := MustParseVersion("v1.beta.02.stable.0.stable")
+	b := MustParseVersion("v01.beta0.2.stable.0.stable")
+	if !a.Equal(b) || a.Canonical() != b.Canonical() {
+		t.Errorf("%s and %s should be equal", a, b)
+	}
+	if a.Equal(MustParseVersion("v1.beta1.2.stable.0.stable")) {
+		t.Error("different iterations compare equal")
+	}
+	// Unknown channels all rank alike, so they share a canonical form.
+	x, y := Version{Major: 1, MinorChannel: Channel(42)}, Version{Major: 1}
+	if !x.Equal(y) || x.Compare(y) != 0 {
+		t.Errorf("Equal = %v, Compare = %d for unknown channels", x.Equal(y), x.Compare(y))
+	}
+}
+
+func TestParseVersionPrefix(t *testing.T) {
+	bare, err := ParseVersion("1.stable.2.beta.0.stable")
+	if err != nil {
+		t.Fatal(err)
+	}
+	prefixed := MustParseVersion("v1.stable.2.beta.0.stable")
+	if bare != prefixed {
+		t.Errorf("bare %+v != prefixed %+v", bare, prefixed)
+	}
+	if got := bare.String(); got != "v1.stable.2.beta.0.stable" {
+		t.Errorf("String() = %q, want the v-prefixed form", got)
+	}
+
+	if v, err := ParseVersionStrict("v1.stable.2.beta.0.stable"); err != nil || v != prefixed {
+		t.Errorf("ParseVersionStrict(prefixed) = %+v, %v", v, err)
+	}
+	if _, err := ParseVersionStrict("1.stable.2.beta.0.stable"); err == nil || !strings.Contains(err.Error(), "'v' prefix") {
+		t.Errorf("ParseVersionStrict(bare) err = %v, want missing prefix", err)
+	}
+	// Strict mode adds only the prefix check.
+	if _, err := ParseVersionStrict("v1.stable.0.stable"); err == nil {
+		t.Error("ParseVersionStrict accepted a short version")
+	}
+}
+
+func TestParseLenient(t *testing.T) {
+	for _, raw := range []string{
+		"v1.stable.2.nightly.0.stable",
+		"1.quantum3.2.stable.0.nightly",
+	} {
+		v, err := ParseLenient(raw)
+		if err != nil {
+			t.Fatalf("ParseLenient(%q): %v", raw, err)
+		}
+		if want := "v" + strings.TrimPrefix(raw, "v"); !v.Degraded || v.String() != want {
+			t.Errorf("ParseLenient(%q) = %+v, want Degraded with String() %q", raw, v, want)
+		}
+		if _, err := ParseVersion(raw); err == nil {
+			t.Errorf("ParseVersion(%q) accepted unknown channels", raw)
+		}
+	}
+
+	v, _ := ParseLenient("v1.quantum3.2.stable.0.nightly")
+	if v.Major != 1 || v.Minor != 2 || v.MinorChannel != ChannelStable || v.MajorChannel != ChannelUnknown {
+		t.Errorf("known components not kept: %+v", v)
+	}
+	if got := v.UnknownTokens(); !reflect.DeepEqual(got, []string{"quantum3", "nightly"}) {
+		t.Errorf("UnknownTokens = %q", got)
+	}
+	if w, _ := ParseLenient("v1.quantum4.2.stable.0.nightly"); v.Canonical() == w.Canonical() {
+		t.Error("different unknown channels compare equal")
+	}
+
+	// Known input is not degraded, and bad structure still fails.
+	if v, err := ParseLenient("v1.stable.2.beta.0.stable"); err != nil || v.Degraded || v != MustParseVersion("v1.stable.2.beta.0.stable") {
+		t.Errorf("ParseLenient of a valid version = %+v, %v", v, err)
+	}
+	for _, bad := range []string{"", "v1.nightly.0", "vX.nightly.0.stable.0.stable", "v1..0.stable.0.stable"} {
+		if _, err := ParseLenient(bad); err == nil {
+			t.Errorf("ParseLenient(%q) succeeded", bad)
+		}
+	}
+}
+
+func FuzzParseVersion(f *testing.F) {
+	for _, seed := range []string{
+		"v1.stable.0.stable.0.stable",
+		"1.stable.2.beta3.0.stable",
+		"v0.legacy.1.experimental.2.alpha",
+		"v3.beta.0.rc2.10.beta02",
+		"v1.stable.0.stable.0.nightly",
+		"v2.3.4",
+		"",
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		v, err := ParseVersion(s)
+		if err != nil {
+			return
+		}
+		out := v.String()
+		w, err := ParseVersion(out)
+		if err != nil {
+			t.Fatalf("ParseVersion(%q) = %s, which does not parse: %v", s, out, err)
+		}
+		if w != v {
+			t.Fatalf("ParseVersion(%q) = %+v, but its String %q parses to %+v", s, v, out, w)
+		}
+		if w.String() != out {
+			t.Fatalf("String not stable for %q: %q then %q", s, out, w.String())
+		}
+	})
+}
@@ -0,0 +1,153 @@
+package semverx
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// wildMask marks the positions of a wildcard term that match any value.
+// Bit i stands for the i-th dot-separated part after the 'v' prefix, so bit
+// 0 (the major number) is never set.
+type wildMask uint8
+
+const (
+	wildMajorChannel wildMask = 1 << (iota + 1)
+	wildMinor
+	wildMinorChannel
+	wildPatch
+	wildPatchChannel
+)
+
+func isWildcard(part string) bool {
+	return part == "*" || part == "x" || part == "X"
+}
+
+// hasWildcard reports whether s has a wildcard part and must be parsed with
+// parseWildcard.
+func hasWildcard(s string) bool {
+	for _, p := range strings.Split(s, ".") {
+		if isWildcard(p) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseWildcard decodes a version pattern. A wildcard in a number position
+// matches any number; in a channel position it matches any channel and
+// iteration. A concrete channel next to a wildcard number must still match
+// exactly, so v1.stable.*.stable.*.stable admits only all-stable 1.x
+// releases while v1.stable.*.*.*.* admits any channel below the major.
+//
+// A pattern may stop early with a trailing wildcard that covers every
+// remaining position: v1.x is any version with major 1, whatever its
+// channels, and v1.stable.2.x fixes the major channel and the minor number.
+// The major number is always concrete, and the major channel may only be
+// left open by such a trailing wildcard, as in v1.x. As with ParseVersion,
+// the 'v' prefix is optional.
+func parseWildcard(s string) (Version, wildMask, error) {
+	parts := strings.Split(strings.TrimPrefix(s, "v"), ".")
+	if len(parts) > 6 {
+		return Version{}, 0, errorf(ErrMalformed, "semverx: version pattern %q has more than 6 dot-separated parts", s)
+	}
+	last := len(parts) - 1
+	if len(parts) < 6 && !isWildcard(parts[last]) {
+		return Version{}, 0, errorf(ErrMalformed, "semverx: partial version %q must end in a wildcard", s)
+	}
+	if isWildcard(parts[0]) {
+		return Version{}, 0, errorf(ErrMalformed, "semverx: version pattern %q: wildcard not allowed in the major position", s)
+	}
+	if len(parts) > 2 && isWildcard(parts[1]) {
+		return Version{}, 0, errorf(ErrMalformed, "semverx: version pattern %q: wildcard not allowed in the major channel position", s)
+	}
+
+	var mask wildMask
+	full := make([]string, 6)
+	for i := range full {
+		switch {
+		case i >= len(parts) || isWildcard(parts[i]):
+			mask |= 1 << i
+			// Placeholders keep ParseVersion happy; the mask hides them.
+			if i%2 == 0 {
+				full[i] = "0"
+			} else {
+				full[i] = ChannelStable.String()
+			}
+		default:
+			full[i] = parts[i]
+		}
+	}
+	v, err := ParseVersion("v" + strings.Join(full, "."))
+	if err != nil {
+		return Version{}, 0, fmt.Errorf("semverx: version pattern %q: %w", s, unwrapVersionError(err))
+	}
+	return v, mask, nil
+}
+
+// unwrapVersionError strips the context ParseVersion adds, which refers to
+// the placeholder-filled string rather than the user's pattern. Errors
+// that carry their own message are kept whole.
+func unwrapVersionError(err error) error {
+	if _, ok := err.(*kindError); ok {
+		return err
+	}
+	if u, ok := err.(interface{ Unwrap() error }); ok && u.Unwrap() != nil {
+		return u.Unwrap()
+	}
+	return err
+}
+
+// matchesPattern reports whether v agrees with p in every position mask
+// leaves concrete.
+func matchesPattern(v, p Version, mask wildMask) bool {
+	same := [6]bool{
+		v.Major == p.Major,
+		v.MajorChannel == p.MajorChannel && v.MajorIteration == p.MajorIteration,
+		v.Minor == p.Minor,
+		v.MinorChannel == p.MinorChannel && v.MinorIteration == p.MinorIteration,
+		v.Patch == p.Patch,
+		v.PatchChannel == p.PatchChannel && v.PatchIteration == p.PatchIteration,
+	}
+	for i, ok := range same {
+		if !ok && mask&(1<<i) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// patternBounds returns a numeric range enclosing every version the pattern
+// matches: from the concrete number prefix, with lower positions zeroed and
+// channels ChannelUnknown, up to but excluding the next value of the last
+// concrete number, or open when that number is already math.MaxInt.
+func patternBounds(p Version, mask wildMask) (lo, hi bound) {
+	l, h := Version{Major: p.Major}, nextBound(p.Major)
+	if mask&wildMinor == 0 {
+		l.Minor, h = p.Minor, nextBound(p.Major, p.Minor)
+		if mask&wildPatch == 0 {
+			l.Patch, h = p.Patch, nextBound(p.Major, p.Minor, p.Patch)
+		}
+	}
+	return bound{l, true, true}, h
+}
+
+// formatPattern renders a pattern so that parseWildcard reads it back:
+// trailing wildcards collapse into a single "*".
+func formatPattern(p Version, mask wildMask) string {
+	parts := []string{
+		strconv.Itoa(p.Major), formatChannel(p.MajorChannel, p.MajorIteration),
+		strconv.Itoa(p.Minor), formatChannel(p.MinorChannel, p.MinorIteration),
+		strconv.Itoa(p.Patch), formatChannel(p.PatchChannel, p.PatchIteration),
+	}
+	for i := range parts {
+		if mask&(1<<i) != 0 {
+			parts[i] = "*"
+		}
+	}
+	n := len(parts)
+	for n > 1 && parts[n-1] == "*" && parts[n-2] == "*" {
+		n--
+	}
+	return "v" + strings.Join(parts[:n], ".")
+}
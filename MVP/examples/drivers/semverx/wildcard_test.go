@@ -0,0 +1,83 @@
+package semverx
+
+import "testing"
+
+func TestWildcardMatches(t *testing.T) {
+	for _, tt := range []struct {
+		constraint, version string
+		want                bool
+	}{
+		{"v1.stable.*.stable.*.stable", "v1.stable.5.stable.3.stable", true},
+		{"v1.stable.*.stable.*.stable", "v1.stable.0.stable.0.stable", true},
+		{"v1.stable.*.stable.*.stable", "v1.stable.5.beta.3.stable", false},
+		{"v1.stable.*.stable.*.stable", "v2.stable.5.stable.3.stable", false},
+		{"=v1.stable.x.stable.x.stable", "v1.stable.5.stable.3.stable", true},
+		{"v1.stable.2.stable.*.stable", "v1.stable.2.stable.9.stable", true},
+		{"v1.stable.2.stable.*.stable", "v1.stable.3.stable.0.stable", false},
+		{"v1.stable.*.*.*.*", "v1.stable.4.alpha2.0.rc", true},
+		{"v1.stable.*.*.*.*", "v1.beta.4.stable.0.stable", false},
+		{"v1.stable.2.beta2.*.stable", "v1.stable.2.beta2.7.stable", true},
+		{"v1.stable.2.beta2.*.stable", "v1.stable.2.beta.7.stable", false},
+		{"v1.x", "v1.beta.7.alpha.3.rc", true},
+		{"v1.*", "v2.stable.0.stable.0.stable", false},
+		{"v1.stable.2.x", "v1.stable.2.beta.0.alpha", true},
+		{"v1.stable.2.x", "v1.stable.3.stable.0.stable", false},
+		{"1.stable.*.stable.*.stable", "v1.stable.5.stable.3.stable", true},
+		{"1.x", "v1.beta.0.stable.0.stable", true},
+		{"v1.x, >=v1.stable.2.stable.0.stable", "v1.stable.2.stable.0.stable", true},
+		{"v1.x, >=v1.stable.2.stable.0.stable", "v1.stable.1.stable.0.stable", false},
+		{"v" + maxInt + ".x, >=v" + maxInt + ".stable.1.stable.0.stable", "v" + maxInt + ".stable.2.stable.0.stable", true},
+		{"v1.stable." + maxInt + ".stable.*.stable", "v1.stable." + maxInt + ".stable.4.stable", true},
+	} {
+		c, err := ParseConstraint(tt.constraint)
+		if err != nil {
+			t.Fatalf("ParseConstraint(%q): %v", tt.constraint, err)
+		}
+		if got := c.Matches(MustParseVersion(tt.version)); got != tt.want {
+			t.Errorf("%q.Matches(%s) = %v, want %v", tt.constraint, tt.version, got, tt.want)
+		}
+	}
+}
+
+func TestWildcardErrors(t *testing.T) {
+	for _, s := range []string{
+		"v*.stable.0.stable.0.stable",
+		"vx",
+		"v1.*.0.stable.0.stable",
+		"v1.x.2.x",
+		"v1.stable.2",
+		"v1.stable.*.stable.*.stable.*",
+		"x.stable.0.stable.0.stable",
+		"v1.stable.*.bogus.*.stable",
+		">=v1.stable.*.stable.*.stable",
+		"^v1.x",
+		// Disjoint from the wildcard's range.
+		"v1.x, >=v2.stable.0.stable.0.stable",
+		"v1.stable.2.x, <v1.stable.1.stable.9.stable",
+	} {
+		if _, err := ParseConstraint(s); err == nil {
+			t.Errorf("ParseConstraint(%q) succeeded, want error", s)
+		}
+	}
+}
+
+func TestWildcardString(t *testing.T) {
+	for in, want := range map[string]string{
+		"v1.stable.x.stable.X.stable": "v1.stable.*.stable.*.stable",
+		"v1.x":                        "v1.*",
+		"v1.stable.*.*.*.*":           "v1.stable.*",
+		"=v1.stable.2.beta3.*":        "v1.stable.2.beta3.*",
+	} {
+		c, err := ParseConstraint(in)
+		if err != nil {
+			t.Fatalf("ParseConstraint(%q): %v", in, err)
+		}
+		got := c.String()
+		if got != want {
+			t.Errorf("%q.String() = %q, want %q", in, got, want)
+		}
+		if _, err := ParseConstraint(got); err != nil {
+			t.Errorf("String() output %q does not parse: %v", got, err)
+		}
+	}
+}
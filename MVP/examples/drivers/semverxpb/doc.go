@@ -0,0 +1,9 @@
+// Package semverxpb holds the protobuf encoding of the driver's wire types.
+// The message types in semverx.pb.go are generated by protoc-gen-go from
+// semverx.proto.
+package semverxpb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative semverx.proto
+
+// ContentType is the media type for protobuf-encoded bodies.
+const ContentType = "application/x-protobuf"
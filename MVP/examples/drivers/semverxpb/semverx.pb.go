@@ -0,0 +1,428 @@
+// Wire schema for the SemVerX driver endpoints. semverx.pb.go is generated
+// from it; run go generate after changing it.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.12
+// 	protoc        (unknown)
+// source: semverx.proto
+
+package semverxpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Version is the /version response body.
+type Version struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Major         uint32                 `protobuf:"varint,1,opt,name=major,proto3" json:"major,omitempty"`
+	MajorChannel  string                 `protobuf:"bytes,2,opt,name=major_channel,json=majorChannel,proto3" json:"major_channel,omitempty"`
+	Minor         uint32                 `protobuf:"varint,3,opt,name=minor,proto3" json:"minor,omitempty"`
+	MinorChannel  string                 `protobuf:"bytes,4,opt,name=minor_channel,json=minorChannel,proto3" json:"minor_channel,omitempty"`
+	Patch         uint32                 `protobuf:"varint,5,opt,name=patch,proto3" json:"patch,omitempty"`
+	PatchChannel  string                 `protobuf:"bytes,6,opt,name=patch_channel,json=patchChannel,proto3" json:"patch_channel,omitempty"`
+	Raw           string                 `protobuf:"bytes,7,opt,name=raw,proto3" json:"raw,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Version) Reset() {
+	*x = Version{}
+	mi := &file_semverx_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Version) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Version) ProtoMessage() {}
+
+func (x *Version) ProtoReflect() protoreflect.Message {
+	mi := &file_semverx_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Version.ProtoReflect.Descriptor instead.
+func (*Version) Descriptor() ([]byte, []int) {
+	return file_semverx_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Version) GetMajor() uint32 {
+	if x != nil {
+		return x.Major
+	}
+	return 0
+}
+
+func (x *Version) GetMajorChannel() string {
+	if x != nil {
+		return x.MajorChannel
+	}
+	return ""
+}
+
+func (x *Version) GetMinor() uint32 {
+	if x != nil {
+		return x.Minor
+	}
+	return 0
+}
+
+func (x *Version) GetMinorChannel() string {
+	if x != nil {
+		return x.MinorChannel
+	}
+	return ""
+}
+
+func (x *Version) GetPatch() uint32 {
+	if x != nil {
+		return x.Patch
+	}
+	return 0
+}
+
+func (x *Version) GetPatchChannel() string {
+	if x != nil {
+		return x.PatchChannel
+	}
+	return ""
+}
+
+func (x *Version) GetRaw() string {
+	if x != nil {
+		return x.Raw
+	}
+	return ""
+}
+
+// HealthCheck is the outcome of one readiness check.
+type HealthCheck struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Ok            bool                   `protobuf:"varint,2,opt,name=ok,proto3" json:"ok,omitempty"`
+	Error         string                 `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HealthCheck) Reset() {
+	*x = HealthCheck{}
+	mi := &file_semverx_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HealthCheck) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HealthCheck) ProtoMessage() {}
+
+func (x *HealthCheck) ProtoReflect() protoreflect.Message {
+	mi := &file_semverx_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HealthCheck.ProtoReflect.Descriptor instead.
+func (*HealthCheck) Descriptor() ([]byte, []int) {
+	return file_semverx_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *HealthCheck) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *HealthCheck) GetOk() bool {
+	if x != nil {
+		return x.Ok
+	}
+	return false
+}
+
+func (x *HealthCheck) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+// Health is the /health and /health/ready response body.
+type Health struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ServiceId     string                 `protobuf:"bytes,1,opt,name=service_id,json=serviceId,proto3" json:"service_id,omitempty"`
+	Healthy       bool                   `protobuf:"varint,2,opt,name=healthy,proto3" json:"healthy,omitempty"`
+	Version       string                 `protobuf:"bytes,3,opt,name=version,proto3" json:"version,omitempty"`
+	Checks        []*HealthCheck         `protobuf:"bytes,4,rep,name=checks,proto3" json:"checks,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Health) Reset() {
+	*x = Health{}
+	mi := &file_semverx_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Health) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Health) ProtoMessage() {}
+
+func (x *Health) ProtoReflect() protoreflect.Message {
+	mi := &file_semverx_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Health.ProtoReflect.Descriptor instead.
+func (*Health) Descriptor() ([]byte, []int) {
+	return file_semverx_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Health) GetServiceId() string {
+	if x != nil {
+		return x.ServiceId
+	}
+	return ""
+}
+
+func (x *Health) GetHealthy() bool {
+	if x != nil {
+		return x.Healthy
+	}
+	return false
+}
+
+func (x *Health) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *Health) GetChecks() []*HealthCheck {
+	if x != nil {
+		return x.Checks
+	}
+	return nil
+}
+
+// ServiceMessage is the /message request body. payload carries the same
+// JSON document as the JSON encoding's payload field.
+type ServiceMessage struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ServiceId     string                 `protobuf:"bytes,1,opt,name=service_id,json=serviceId,proto3" json:"service_id,omitempty"`
+	Version       string                 `protobuf:"bytes,2,opt,name=version,proto3" json:"version,omitempty"`
+	Payload       []byte                 `protobuf:"bytes,3,opt,name=payload,proto3" json:"payload,omitempty"`
+	Timestamp     int64                  `protobuf:"varint,4,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Signature     string                 `protobuf:"bytes,5,opt,name=signature,proto3" json:"signature,omitempty"`
+	Visited       []string               `protobuf:"bytes,6,rep,name=visited,proto3" json:"visited,omitempty"`
+	MessageType   string                 `protobuf:"bytes,7,opt,name=message_type,json=messageType,proto3" json:"message_type,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ServiceMessage) Reset() {
+	*x = ServiceMessage{}
+	mi := &file_semverx_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ServiceMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ServiceMessage) ProtoMessage() {}
+
+func (x *ServiceMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_semverx_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ServiceMessage.ProtoReflect.Descriptor instead.
+func (*ServiceMessage) Descriptor() ([]byte, []int) {
+	return file_semverx_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ServiceMessage) GetServiceId() string {
+	if x != nil {
+		return x.ServiceId
+	}
+	return ""
+}
+
+func (x *ServiceMessage) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *ServiceMessage) GetPayload() []byte {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *ServiceMessage) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *ServiceMessage) GetSignature() string {
+	if x != nil {
+		return x.Signature
+	}
+	return ""
+}
+
+func (x *ServiceMessage) GetVisited() []string {
+	if x != nil {
+		return x.Visited
+	}
+	return nil
+}
+
+func (x *ServiceMessage) GetMessageType() string {
+	if x != nil {
+		return x.MessageType
+	}
+	return ""
+}
+
+var File_semverx_proto protoreflect.FileDescriptor
+
+const file_semverx_proto_rawDesc = "" +
+	"\n" +
+	"\rsemverx.proto\x12\n" +
+	"semverx.v1\"\xcc\x01\n" +
+	"\aVersion\x12\x14\n" +
+	"\x05major\x18\x01 \x01(\rR\x05major\x12#\n" +
+	"\rmajor_channel\x18\x02 \x01(\tR\fmajorChannel\x12\x14\n" +
+	"\x05minor\x18\x03 \x01(\rR\x05minor\x12#\n" +
+	"\rminor_channel\x18\x04 \x01(\tR\fminorChannel\x12\x14\n" +
+	"\x05patch\x18\x05 \x01(\rR\x05patch\x12#\n" +
+	"\rpatch_channel\x18\x06 \x01(\tR\fpatchChannel\x12\x10\n" +
+	"\x03raw\x18\a \x01(\tR\x03raw\"G\n" +
+	"\vHealthCheck\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x0e\n" +
+	"\x02ok\x18\x02 \x01(\bR\x02ok\x12\x14\n" +
+	"\x05error\x18\x03 \x01(\tR\x05error\"\x8c\x01\n" +
+	"\x06Health\x12\x1d\n" +
+	"\n" +
+	"service_id\x18\x01 \x01(\tR\tserviceId\x12\x18\n" +
+	"\ahealthy\x18\x02 \x01(\bR\ahealthy\x12\x18\n" +
+	"\aversion\x18\x03 \x01(\tR\aversion\x12/\n" +
+	"\x06checks\x18\x04 \x03(\v2\x17.semverx.v1.HealthCheckR\x06checks\"\xdc\x01\n" +
+	"\x0eServiceMessage\x12\x1d\n" +
+	"\n" +
+	"service_id\x18\x01 \x01(\tR\tserviceId\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\tR\aversion\x12\x18\n" +
+	"\apayload\x18\x03 \x01(\fR\apayload\x12\x1c\n" +
+	"\ttimestamp\x18\x04 \x01(\x03R\ttimestamp\x12\x1c\n" +
+	"\tsignature\x18\x05 \x01(\tR\tsignature\x12\x18\n" +
+	"\avisited\x18\x06 \x03(\tR\avisited\x12!\n" +
+	"\fmessage_type\x18\a \x01(\tR\vmessageTypeBAZ?github.com/obinexus/rust-semverx/MVP/examples/drivers/semverxpbb\x06proto3"
+
+var (
+	file_semverx_proto_rawDescOnce sync.Once
+	file_semverx_proto_rawDescData []byte
+)
+
+func file_semverx_proto_rawDescGZIP() []byte {
+	file_semverx_proto_rawDescOnce.Do(func() {
+		file_semverx_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_semverx_proto_rawDesc), len(file_semverx_proto_rawDesc)))
+	})
+	return file_semverx_proto_rawDescData
+}
+
+var file_semverx_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_semverx_proto_goTypes = []any{
+	(*Version)(nil),        // 0: semverx.v1.Version
+	(*HealthCheck)(nil),    // 1: semverx.v1.HealthCheck
+	(*Health)(nil),         // 2: semverx.v1.Health
+	(*ServiceMessage)(nil), // 3: semverx.v1.ServiceMessage
+}
+var file_semverx_proto_depIdxs = []int32{
+	1, // 0: semverx.v1.Health.checks:type_name -> semverx.v1.HealthCheck
+	1, // [1:1] is the sub-list for method output_type
+	1, // [1:1] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_semverx_proto_init() }
+func file_semverx_proto_init() {
+	if File_semverx_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_semverx_proto_rawDesc), len(file_semverx_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   4,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_semverx_proto_goTypes,
+		DependencyIndexes: file_semverx_proto_depIdxs,
+		MessageInfos:      file_semverx_proto_msgTypes,
+	}.Build()
+	File_semverx_proto = out.File
+	file_semverx_proto_goTypes = nil
+	file_semverx_proto_depIdxs = nil
+}
@@ -0,0 +1,80 @@
+package semverxpb
+
+import (
+	"bytes"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// The expected encodings are worked out by hand from the protobuf wire
+// format, pinning the schema's field numbers and types.
+
+func TestVersionWireFormat(t *testing.T) {
+	v := &Version{Major: 1, MajorChannel: "stable", Minor: 300, Raw: "r"}
+	want := []byte{
+		0x08, 0x01, // major = 1
+		0x12, 0x06, 's', 't', 'a', 'b', 'l', 'e', // major_channel
+		0x18, 0xac, 0x02, // minor = 300
+		0x3a, 0x01, 'r', // raw
+	}
+	got, err := proto.Marshal(v)
+	if err != nil || !bytes.Equal(got, want) {
+		t.Fatalf("Marshal = % x, %v; want % x", got, err, want)
+	}
+	var back Version
+	if err := proto.Unmarshal(want, &back); err != nil || !proto.Equal(&back, v) {
+		t.Errorf("Unmarshal = %v, %v", &back, err)
+	}
+}
+
+func TestHealthRoundTrip(t *testing.T) {
+	h := &Health{ServiceId: "svc", Healthy: true, Version: "v1.stable.0.stable.0.stable", Checks: []*HealthCheck{
+		{Name: "peers", Ok: true},
+		{Name: "db", Error: "down"},
+		{},
+	}}
+	b, err := proto.Marshal(h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var back Health
+	if err := proto.Unmarshal(b, &back); err != nil {
+		t.Fatal(err)
+	}
+	if !proto.Equal(&back, h) {
+		t.Errorf("round trip = %v, want %v", &back, h)
+	}
+}
+
+func TestServiceMessageWireFormat(t *testing.T) {
+	m := &ServiceMessage{ServiceId: "a", Payload: []byte("{}"), Timestamp: -1, Visited: []string{"x", ""}}
+	want := []byte{
+		0x0a, 0x01, 'a', // service_id
+		0x1a, 0x02, '{', '}', // payload
+		0x20, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01, // timestamp = -1
+		0x32, 0x01, 'x', 0x32, 0x00, // visited
+	}
+	got, err := proto.Marshal(m)
+	if err != nil || !bytes.Equal(got, want) {
+		t.Fatalf("Marshal = % x, %v; want % x", got, err, want)
+	}
+	var back ServiceMessage
+	if err := proto.Unmarshal(want, &back); err != nil || !proto.Equal(&back, m) {
+		t.Errorf("Unmarshal = %v, %v", &back, err)
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	for name, b := range map[string][]byte{
+		"truncated tag":    {0x80},
+		"truncated length": {0x0a, 0x05, 'a'},
+		"field zero":       {0x00, 0x01},
+		"truncated varint": {0x08, 0x80},
+	} {
+		var m ServiceMessage
+		if err := proto.Unmarshal(b, &m); err == nil {
+			t.Errorf("%s: Unmarshal(% x) succeeded", name, b)
+		}
+	}
+}
@@ -0,0 +1,344 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/obinexus/rust-semverx/MVP/examples/drivers/semverx"
+)
+
+// Server owns the HTTP handlers of a driver node.
+type Server struct {
+	cfg         Config
+	log         *slog.Logger
+	peers       *PeerRegistry
+	peerStore   *FileStore
+	replay      *replayGuard
+	idempotency *idempotencyCache
+	limiter     *rateLimiter
+	forwarder   *Forwarder
+	checkers    []HealthChecker
+	payloads    *PayloadCodec
+	bus         *Bus
+	acl         atomic.Pointer[ACL]
+
+	metrics        Metrics
+	metricsHandler http.Handler
+	tracer         trace.Tracer
+
+	// shutdown is closed when Serve begins shutting down, telling /stream
+	// connections, which http.Server does not track, to close.
+	shutdown     chan struct{}
+	shutdownOnce sync.Once
+}
+
+// ServerOption adjusts how NewServer builds a Server.
+type ServerOption func(*serverOptions)
+
+type serverOptions struct {
+	logOutput io.Writer
+}
+
+// WithLogOutput sends the server's JSON log to w instead of stderr.
+func WithLogOutput(w io.Writer) ServerOption {
+	return func(o *serverOptions) { o.logOutput = w }
+}
+
+// NewServer returns a Server for cfg that logs JSON to stderr.
+func NewServer(cfg Config, opts ...ServerOption) *Server {
+	o := serverOptions{logOutput: os.Stderr}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	s := &Server{
+		cfg:   cfg,
+		log:   slog.New(slog.NewJSONHandler(o.logOutput, &slog.HandlerOptions{Level: cfg.LogLevel})).With("service_id", cfg.ServiceID),
+		peers: NewPeerRegistry(cfg.PeerTTL),
+
+		payloads: NewPayloadCodec(),
+		bus:      NewBus(cfg.BusBuffer, cfg.BusOverflow),
+
+		shutdown: make(chan struct{}),
+	}
+	s.forwarder = &Forwarder{
+		Self:     cfg.ServiceID,
+		Local:    cfg.Version,
+		Registry: s.peers,
+		URLs:     cfg.PeerURLs,
+		Client:   &http.Client{},
+		MaxHops:  cfg.MaxHops,
+		Timeout:  cfg.ForwardTimeout,
+	}
+	if cfg.PeerStore != "" {
+		s.peerStore = NewFileStore(cfg.PeerStore)
+		logStoreError := func(err error) { s.log.Warn("peer store", "err", err) }
+		if err := s.peers.UseStore(s.peerStore, logStoreError); err != nil {
+			s.log.Warn("skipping unreadable peer snapshot", "path", cfg.PeerStore, "err", err)
+		}
+	}
+	s.SetACL(cfg.ACL)
+	if cfg.ReadyPeerGrace > 0 {
+		s.AddHealthCheck(peersCheck(s.peers, time.Now(), cfg.ReadyPeerGrace))
+	}
+	for _, u := range cfg.Upstreams {
+		s.AddHealthCheck(upstreamCheck(s.forwarder.Client, u))
+	}
+	s.SetTracerProvider(otel.GetTracerProvider())
+	prom := newPromMetrics()
+	s.metrics, s.metricsHandler = prom, prom.handler()
+	if cfg.ReplayWindow > 0 {
+		s.replay = newReplayGuard(cfg.ReplayWindow)
+	}
+	if cfg.IdempotencyTTL > 0 {
+		s.idempotency = newIdempotencyCache(cfg.IdempotencyTTL)
+	}
+	if cfg.RateLimit.enabled() || cfg.AnonRateLimit.enabled() {
+		s.limiter = newRateLimiter(cfg.RateLimit, cfg.AnonRateLimit)
+	}
+	return s
+}
+
+// Payloads returns the codec accepted messages are dispatched through.
+func (s *Server) Payloads() *PayloadCodec {
+	return s.payloads
+}
+
+// Bus returns the bus accepted messages are published on.
+func (s *Server) Bus() *Bus {
+	return s.bus
+}
+
+// Routes returns a fresh mux with all endpoints registered.
+func (s *Server) Routes() http.Handler {
+	mux := http.NewServeMux()
+	handle := func(route string, h http.HandlerFunc) {
+		mux.Handle(route, s.instrument(route, s.traced(route, h)))
+	}
+	bounded := func(route string, h http.Handler) {
+		mux.Handle(route, s.instrument(route, s.traced(route, s.withMaxBody(h))))
+	}
+	limited := func(route string, h http.Handler) { bounded(route, s.withRateLimit(h)) }
+	handle("/health", s.healthHandler)
+	handle("/health/ready", s.healthHandler)
+	handle("/health/live", s.liveHandler)
+	// The body is decoded once, before the rate limit, for everything
+	// behind it. Idempotent retries are answered before the replay guard
+	// would reject them as duplicates.
+	bounded("/message", s.withMessage(s.withRateLimit(s.withRequiredHeaders(s.withIdempotency(s.withReplayProtection(http.HandlerFunc(s.messageHandler)))))))
+	handle("/version", s.versionHandler)
+	handle("/peers", s.peersHandler)
+	limited("/negotiate", http.HandlerFunc(s.negotiateHandler))
+	limited("/messages/batch", http.HandlerFunc(s.batchHandler))
+	limited("/compat", http.HandlerFunc(s.compatHandler))
+	bounded("/admin/acl", http.HandlerFunc(s.aclHandler))
+	mux.Handle("/metrics", s.metricsHandler)
+	// Streams are long-lived, so their duration is not a handler latency.
+	mux.HandleFunc("/stream", s.streamHandler)
+	return s.logRequests(s.withCORS(mux))
+}
+
+// ListenAndServe listens on the configured address and serves until ctx is
+// cancelled.
+func (s *Server) ListenAndServe(ctx context.Context) error {
+	ln, err := net.Listen("tcp", s.cfg.Addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(ctx, ln)
+}
+
+// Serve serves on ln until ctx is cancelled, then shuts down gracefully:
+// in-flight requests get up to cfg.ShutdownGrace to finish before their
+// connections are closed. A configured peer store is snapshotted
+// periodically and once more on the way out. A clean shutdown returns nil.
+func (s *Server) Serve(ctx context.Context, ln net.Listener) error {
+	hs := &http.Server{
+		Handler:           s.Routes(),
+		ReadHeaderTimeout: s.cfg.ReadHeaderTimeout,
+		ReadTimeout:       s.cfg.ReadTimeout,
+		WriteTimeout:      s.cfg.WriteTimeout,
+		IdleTimeout:       s.cfg.IdleTimeout,
+	}
+	hs.RegisterOnShutdown(func() { s.shutdownOnce.Do(func() { close(s.shutdown) }) })
+	defer s.snapshotPeers()()
+	errc := make(chan error, 1)
+	go func() { errc <- hs.Serve(ln) }()
+	s.log.Info("listening", "addr", ln.Addr().String(), "version", s.cfg.Version.String())
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+
+	s.log.Info("shutting down", "grace", s.cfg.ShutdownGrace)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.cfg.ShutdownGrace)
+	defer cancel()
+	if err := hs.Shutdown(shutdownCtx); err != nil {
+		hs.Close()
+		return fmt.Errorf("shutdown: %w", err)
+	}
+	if err := <-errc; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	s.log.Info("shutdown complete")
+	return nil
+}
+
+// VersionInfo is the /version response body.
+type VersionInfo struct {
+	Major        int    `json:"major"`
+	Minor        int    `json:"minor"`
+	Patch        int    `json:"patch"`
+	MajorChannel string `json:"major_channel"`
+	MinorChannel string `json:"minor_channel"`
+	PatchChannel string `json:"patch_channel"`
+	Raw          string `json:"raw"`
+}
+
+func newVersionInfo(v semverx.Version) VersionInfo {
+	return VersionInfo{
+		Major:        v.Major,
+		Minor:        v.Minor,
+		Patch:        v.Patch,
+		MajorChannel: v.MajorChannel.String(),
+		MinorChannel: v.MinorChannel.String(),
+		PatchChannel: v.PatchChannel.String(),
+		Raw:          v.String(),
+	}
+}
+
+func (s *Server) versionHandler(w http.ResponseWriter, r *http.Request) {
+	info := newVersionInfo(s.cfg.Version)
+	writeNegotiated(w, r, http.StatusOK, info)
+}
+
+// PeerInfo is an entry of the /peers response body.
+type PeerInfo struct {
+	ServiceID  string    `json:"service_id"`
+	Version    string    `json:"version"`
+	LastSeen   time.Time `json:"last_seen"`
+	Negotiated string    `json:"negotiated_version,omitempty"`
+	// Degraded marks a Version with channels this node does not recognize.
+	Degraded bool `json:"degraded,omitempty"`
+}
+
+func (s *Server) peersHandler(w http.ResponseWriter, r *http.Request) {
+	peers := s.peers.List()
+	out := make([]PeerInfo, len(peers))
+	for i, p := range peers {
+		out[i] = PeerInfo{ServiceID: p.ServiceID, Version: p.Version.String(), LastSeen: p.LastSeen, Degraded: p.Version.Degraded}
+		if p.Negotiated != nil {
+			out[i].Negotiated = p.Negotiated.String()
+		}
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// NegotiateRequest is the /negotiate request body. Timestamp and Signature
+// are required when the server has a shared secret; see SignNegotiation.
+type NegotiateRequest struct {
+	ServiceID  string `json:"service_id"`
+	Constraint string `json:"constraint"`
+	Timestamp  int64  `json:"timestamp,omitempty"`
+	Signature  string `json:"signature,omitempty"`
+}
+
+// NegotiateResponse is the /negotiate response body.
+type NegotiateResponse struct {
+	ServiceID string `json:"service_id"`
+	Version   string `json:"version"`
+}
+
+// negotiateHandler picks the highest supported local version that satisfies
+// the peer's constraint and, for a registered peer, caches the result in
+// the peer registry.
+func (s *Server) negotiateHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeError(w, http.StatusMethodNotAllowed, "negotiate requires POST")
+		return
+	}
+	var req NegotiateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeBodyError(w, "invalid negotiate body", err)
+		return
+	}
+	if req.ServiceID == "" {
+		writeError(w, http.StatusBadRequest, "service_id is required")
+		return
+	}
+	setSender(r, req.ServiceID)
+	if len(s.cfg.Secret) > 0 {
+		if err := VerifyNegotiation(req, s.cfg.Secret); err != nil {
+			s.metrics.Negotiation("unauthorized")
+			writeError(w, http.StatusUnauthorized, err.Error())
+			return
+		}
+	}
+	if merr := s.checkACL(req.ServiceID); merr != nil {
+		s.metrics.Negotiation("policy")
+		writeJSON(w, merr.status, merr.fields)
+		return
+	}
+	c, err := semverx.ParseConstraint(req.Constraint)
+	if err != nil {
+		s.metrics.Negotiation("invalid")
+		merr := rejectInvalid(http.StatusBadRequest, err.Error(), err)
+		writeJSON(w, merr.status, merr.fields)
+		return
+	}
+
+	var best *semverx.Version
+	var blocked *messageError
+	for _, v := range s.cfg.supportedVersions() {
+		if !c.Matches(v) {
+			continue
+		}
+		if merr := s.checkStability(v); merr != nil {
+			blocked = merr
+			continue
+		}
+		if best == nil || best.Less(v) {
+			v := v
+			best = &v
+		}
+	}
+	if best == nil && blocked != nil {
+		s.metrics.Negotiation("policy")
+		blocked.status = http.StatusNotAcceptable
+		writeJSON(w, blocked.status, blocked.fields)
+		return
+	}
+	if best == nil {
+		s.metrics.Negotiation("no_match")
+		writeError(w, http.StatusNotAcceptable, fmt.Sprintf("no supported version satisfies %q", c))
+		return
+	}
+	s.peers.SetNegotiated(req.ServiceID, *best)
+	s.metrics.Negotiation("ok")
+	writeJSON(w, http.StatusOK, NegotiateResponse{ServiceID: s.cfg.ServiceID, Version: best.String()})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
@@ -0,0 +1,380 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/obinexus/rust-semverx/MVP/examples/drivers/semverx"
+)
+
+func newTestServer() *Server {
+	return NewServer(DefaultConfig(), WithLogOutput(io.Discard))
+}
+
+// newMessageRequest returns a /message request for the JSON body carrying
+// the headers required by default, with X-Service-ID taken from the body.
+func newMessageRequest(target, body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+	setMessageHeaders(req, body)
+	return req
+}
+
+// postServiceMessage posts the JSON body to a node's /message like
+// newMessageRequest.
+func postServiceMessage(url, body string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	setMessageHeaders(req, body)
+	return http.DefaultClient.Do(req)
+}
+
+func setMessageHeaders(req *http.Request, body string) {
+	var msg struct {
+		ServiceID string `json:"service_id"`
+	}
+	json.Unmarshal([]byte(body), &msg)
+	req.Header.Set("Content-Type", mediaJSON)
+	if msg.ServiceID != "" {
+		req.Header.Set(serviceIDHeader, msg.ServiceID)
+	}
+}
+
+// postMessage posts body to srv's message handler, bypassing the other
+// /message middleware.
+func postMessage(t *testing.T, srv *Server, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/message", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	srv.withMessage(http.HandlerFunc(srv.messageHandler)).ServeHTTP(rec, req)
+	return rec
+}
+
+func TestMessageHandlerAccepts(t *testing.T) {
+	rec := postMessage(t, newTestServer(), `{"service_id":"rust-service","version":"v1.stable.0.stable.0.stable","payload":{},"timestamp":1700000000}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", rec.Code, rec.Body)
+	}
+	if got := rec.Body.String(); got != "Message received" {
+		t.Errorf("body = %q", got)
+	}
+}
+
+func TestMessageHandlerRejects(t *testing.T) {
+	tests := map[string]string{
+		"malformed json":     `{"service_id":`,
+		"empty service id":   `{"service_id":"","version":"v1.stable.0.stable.0.stable","timestamp":1}`,
+		"empty version":      `{"service_id":"rust-service","version":"","timestamp":1}`,
+		"malformed version":  `{"service_id":"rust-service","version":"1.0.0","timestamp":1}`,
+		"zero timestamp":     `{"service_id":"rust-service","version":"v1.stable.0.stable.0.stable","timestamp":0}`,
+		"negative timestamp": `{"service_id":"rust-service","version":"v1.stable.0.stable.0.stable","timestamp":-5}`,
+		"bad component":      `{"service_id":"rust-service","version":"v1.stable.x.stable.0.stable","timestamp":1}`,
+	}
+	// Version parse failures also name their kind.
+	codes := map[string]string{
+		"empty version":     "empty_version",
+		"malformed version": "malformed_version",
+		"bad component":     "bad_component",
+	}
+	for name, body := range tests {
+		t.Run(name, func(t *testing.T) {
+			rec := postMessage(t, newTestServer(), body)
+			if rec.Code != http.StatusBadRequest {
+				t.Fatalf("status = %d, want 400", rec.Code)
+			}
+			var resp map[string]string
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("decode error body: %v", err)
+			}
+			if resp["error"] == "" {
+				t.Errorf("missing error message in %v", resp)
+			}
+			if resp["code"] != codes[name] {
+				t.Errorf("code = %q, want %q", resp["code"], codes[name])
+			}
+		})
+	}
+}
+
+func TestMessageHandlerIncompatible(t *testing.T) {
+	tests := map[string]string{
+		"major mismatch":    "v2.stable.0.stable.0.stable",
+		"channel downgrade": "v1.stable.0.beta.0.stable",
+		"unknown channel":   "v1.nightly.0.stable.0.stable",
+	}
+	for name, version := range tests {
+		t.Run(name, func(t *testing.T) {
+			rec := postMessage(t, newTestServer(), `{"service_id":"rust-service","version":"`+version+`","timestamp":1}`)
+			if rec.Code != http.StatusConflict {
+				t.Fatalf("status = %d, want 409", rec.Code)
+			}
+			var resp map[string]string
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatal(err)
+			}
+			if resp["reason"] == "" || resp["remote_version"] != version {
+				t.Errorf("unexpected body %v", resp)
+			}
+		})
+	}
+}
+
+func TestVersionHandler(t *testing.T) {
+	srv := newTestServer()
+	rec := httptest.NewRecorder()
+	srv.versionHandler(rec, httptest.NewRequest(http.MethodGet, "/version", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d", rec.Code)
+	}
+	var got map[string]interface{}
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"major": 1.0, "minor": 0.0, "patch": 0.0,
+		"major_channel": "stable", "minor_channel": "stable", "patch_channel": "stable",
+		"raw": "v1.stable.0.stable.0.stable",
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s = %v, want %v", k, got[k], v)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	srv.healthHandler(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+	var health map[string]interface{}
+	if err := json.NewDecoder(rec.Body).Decode(&health); err != nil {
+		t.Fatal(err)
+	}
+	if health["version"] != got["raw"] {
+		t.Errorf("/health version %v differs from /version raw %v", health["version"], got["raw"])
+	}
+}
+
+func TestServerReflectsConfig(t *testing.T) {
+	srv := NewServer(Config{
+		Addr:      ":0",
+		ServiceID: "edge-node",
+		Version:   semverx.MustParseVersion("v3.stable.1.beta.4.rc"),
+	}, WithLogOutput(io.Discard))
+
+	rec := httptest.NewRecorder()
+	srv.healthHandler(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+	var health map[string]interface{}
+	if err := json.NewDecoder(rec.Body).Decode(&health); err != nil {
+		t.Fatal(err)
+	}
+	if health["service_id"] != "edge-node" || health["version"] != "v3.stable.1.beta.4.rc" {
+		t.Errorf("/health = %v", health)
+	}
+
+	rec = httptest.NewRecorder()
+	srv.versionHandler(rec, httptest.NewRequest(http.MethodGet, "/version", nil))
+	var info VersionInfo
+	if err := json.NewDecoder(rec.Body).Decode(&info); err != nil {
+		t.Fatal(err)
+	}
+	if info.Major != 3 || info.MinorChannel != "beta" || info.Raw != "v3.stable.1.beta.4.rc" {
+		t.Errorf("/version = %+v", info)
+	}
+
+	rec = postMessage(t, srv, `{"service_id":"peer","version":"v3.stable.1.beta.4.rc","timestamp":1}`)
+	if rec.Code != http.StatusOK {
+		t.Errorf("message from same version: status = %d, body = %s", rec.Code, rec.Body)
+	}
+}
+
+func TestEndpointsEmitPrefixedVersion(t *testing.T) {
+	cfg, err := loadConfig([]string{"-version", "2.stable.1.rc.0.stable"}, func(string) string { return "" }, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := NewServer(cfg, WithLogOutput(io.Discard)).Routes()
+	for _, path := range []string{"/version", "/health"} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if !strings.Contains(rec.Body.String(), `"v2.stable.1.rc.0.stable"`) {
+			t.Errorf("%s = %s, want the v-prefixed version", path, rec.Body)
+		}
+	}
+}
+
+func TestRoutes(t *testing.T) {
+	a := httptest.NewServer(NewServer(Config{ServiceID: "node-a", Version: semverx.MustParseVersion("v1.stable.0.stable.0.stable")}, WithLogOutput(io.Discard)).Routes())
+	defer a.Close()
+	b := httptest.NewServer(NewServer(Config{ServiceID: "node-b", Version: semverx.MustParseVersion("v2.stable.0.stable.0.stable")}, WithLogOutput(io.Discard)).Routes())
+	defer b.Close()
+
+	for _, tt := range []struct {
+		url, id, version string
+	}{
+		{a.URL, "node-a", "v1.stable.0.stable.0.stable"},
+		{b.URL, "node-b", "v2.stable.0.stable.0.stable"},
+	} {
+		resp, err := http.Get(tt.url + "/health")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var health map[string]interface{}
+		err = json.NewDecoder(resp.Body).Decode(&health)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if health["service_id"] != tt.id || health["version"] != tt.version {
+			t.Errorf("%s/health = %v", tt.url, health)
+		}
+
+		resp, err = http.Get(tt.url + "/version")
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("%s/version status = %d", tt.url, resp.StatusCode)
+		}
+	}
+
+	resp, err := postServiceMessage(a.URL+"/message", `{"service_id":"node-b","version":"v2.stable.0.stable.0.stable","timestamp":1}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusConflict {
+		t.Errorf("node-a accepted node-b's major version: status = %d", resp.StatusCode)
+	}
+}
+
+func TestServeShutdown(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := DefaultConfig()
+	cfg.ShutdownGrace = time.Second
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- NewServer(cfg, WithLogOutput(io.Discard)).Serve(ctx, ln) }()
+
+	resp, err := http.Get("http://" + ln.Addr().String() + "/health")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Serve returned %v on clean shutdown", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Serve did not return after cancel")
+	}
+	if _, err := http.Get("http://" + ln.Addr().String() + "/health"); err == nil {
+		t.Error("server still accepting requests after shutdown")
+	}
+}
+
+func TestServeReadTimeout(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := DefaultConfig()
+	cfg.ReadHeaderTimeout = 0
+	cfg.ReadTimeout = 100 * time.Millisecond
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go NewServer(cfg, WithLogOutput(io.Discard)).Serve(ctx, ln)
+
+	// Promise a body and never finish sending it.
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	fmt.Fprint(conn, "POST /message HTTP/1.1\r\nHost: x\r\nContent-Type: application/json\r\nContent-Length: 100\r\n\r\n{\"service_id\":")
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	resp, err := io.ReadAll(conn)
+	if ne, ok := err.(net.Error); ok && ne.Timeout() {
+		t.Fatal("connection still open long after the read timeout")
+	}
+	if strings.HasPrefix(string(resp), "HTTP/1.1 200") {
+		t.Errorf("incomplete request was accepted: %s", resp)
+	}
+}
+
+func TestPeersHandler(t *testing.T) {
+	srv := newTestServer()
+	ts := httptest.NewServer(srv.Routes())
+	defer ts.Close()
+
+	for _, id := range []string{"rust-service", "python-service"} {
+		resp, err := postServiceMessage(ts.URL+"/message", `{"service_id":"`+id+`","version":"v1.stable.1.stable.0.stable","timestamp":1}`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+
+	resp, err := http.Get(ts.URL + "/peers")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var peers []PeerInfo
+	if err := json.NewDecoder(resp.Body).Decode(&peers); err != nil {
+		t.Fatal(err)
+	}
+	if len(peers) != 2 || peers[0].ServiceID != "python-service" || peers[1].Version != "v1.stable.1.stable.0.stable" {
+		t.Errorf("/peers = %+v", peers)
+	}
+}
+
+func TestPeersHandlerDegraded(t *testing.T) {
+	srv := newTestServer()
+	const raw = "v1.stable.1.nightly2.0.stable"
+	rec := postMessage(t, srv, `{"service_id":"future-service","version":"`+raw+`","timestamp":1}`)
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("degraded sender: status = %d, want 409", rec.Code)
+	}
+	rec = httptest.NewRecorder()
+	srv.peersHandler(rec, httptest.NewRequest(http.MethodGet, "/peers", nil))
+	var peers []PeerInfo
+	if err := json.NewDecoder(rec.Body).Decode(&peers); err != nil {
+		t.Fatal(err)
+	}
+	if len(peers) != 1 || !peers[0].Degraded || peers[0].Version != raw {
+		t.Errorf("/peers = %+v, want the degraded sender with its raw version", peers)
+	}
+}
+
+func TestServiceMessageWireFormat(t *testing.T) {
+	wire := `{"service_id":"python-service","version":"v1.stable.0.stable.0.stable","payload":{"op":"ping"},"timestamp":1700000000}`
+	var msg ServiceMessage
+	if err := json.Unmarshal([]byte(wire), &msg); err != nil {
+		t.Fatal(err)
+	}
+	if msg.Version != semverx.MustParseVersion("v1.stable.0.stable.0.stable") {
+		t.Errorf("Version = %+v", msg.Version)
+	}
+	b, err := json.Marshal(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != wire {
+		t.Errorf("re-encoded as %s", b)
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/obinexus/rust-semverx/MVP/examples/drivers/semverx"
+)
+
+func signedMessage(key []byte) ServiceMessage {
+	msg := ServiceMessage{
+		ServiceID: "rust-service",
+		Version:   semverx.MustParseVersion("v1.stable.0.stable.0.stable"),
+		Payload:   json.RawMessage(`{"op": "ping"}`),
+		Timestamp: 1700000000,
+	}
+	SignMessage(&msg, key)
+	return msg
+}
+
+func TestVerifyMessage(t *testing.T) {
+	key := []byte("shared-secret")
+	msg := signedMessage(key)
+	if err := VerifyMessage(msg, key); err != nil {
+		t.Fatalf("VerifyMessage: %v", err)
+	}
+
+	// Re-encoding compacts the payload; the signature must survive that.
+	b, _ := json.Marshal(msg)
+	var wire ServiceMessage
+	if err := json.Unmarshal(b, &wire); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyMessage(wire, key); err != nil {
+		t.Errorf("VerifyMessage after round trip: %v", err)
+	}
+
+	tampered := msg
+	tampered.Payload = json.RawMessage(`{"op":"pong"}`)
+	if err := VerifyMessage(tampered, key); !errors.Is(err, errBadSignature) {
+		t.Errorf("tampered payload: err = %v", err)
+	}
+	tampered = msg
+	tampered.Timestamp++
+	if err := VerifyMessage(tampered, key); !errors.Is(err, errBadSignature) {
+		t.Errorf("tampered timestamp: err = %v", err)
+	}
+	tampered = msg
+	tampered.MessageType = "ping"
+	if err := VerifyMessage(tampered, key); !errors.Is(err, errBadSignature) {
+		t.Errorf("added message type: err = %v", err)
+	}
+	if err := VerifyMessage(msg, []byte("wrong-key")); !errors.Is(err, errBadSignature) {
+		t.Errorf("wrong key: err = %v", err)
+	}
+	unsigned := msg
+	unsigned.Signature = ""
+	if err := VerifyMessage(unsigned, key); !errors.Is(err, errMissingSignature) {
+		t.Errorf("unsigned: err = %v", err)
+	}
+	bogus := msg
+	bogus.Signature = "not-hex"
+	if err := VerifyMessage(bogus, key); !errors.Is(err, errBadSignature) {
+		t.Errorf("non-hex signature: err = %v", err)
+	}
+}
+
+func TestSignatureCoversWireVersion(t *testing.T) {
+	key := []byte("shared-secret")
+	// A peer signs the version as it writes it, here without the 'v'.
+	peer := ServiceMessage{
+		ServiceID:  "python-service",
+		RawVersion: "1.stable.0.stable.0.stable",
+		Payload:    json.RawMessage(`{"op":"ping"}`),
+		Timestamp:  1700000000,
+	}
+	SignMessage(&peer, key)
+	body := `{"service_id":"python-service","version":"1.stable.0.stable.0.stable","payload":{"op":"ping"},"timestamp":1700000000,"signature":"` + peer.Signature + `"}`
+
+	var msg ServiceMessage
+	if err := json.Unmarshal([]byte(body), &msg); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyMessage(msg, key); err != nil {
+		t.Errorf("VerifyMessage: %v", err)
+	}
+	// Relaying re-encodes the message; the peer's signature must survive.
+	b, _ := json.Marshal(msg)
+	var relayed ServiceMessage
+	if err := json.Unmarshal(b, &relayed); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyMessage(relayed, key); err != nil {
+		t.Errorf("VerifyMessage after relay: %v (%s)", err, b)
+	}
+	// Adding the prefix changes what was signed.
+	prefixed := strings.Replace(body, `"1.stable`, `"v1.stable`, 1)
+	if err := json.Unmarshal([]byte(prefixed), &msg); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyMessage(msg, key); !errors.Is(err, errBadSignature) {
+		t.Errorf("re-prefixed version: err = %v, want %v", err, errBadSignature)
+	}
+
+	cfg := DefaultConfig()
+	cfg.Secret = key
+	srv := NewServer(cfg, WithLogOutput(io.Discard))
+	if rec := postMessage(t, srv, body); rec.Code != http.StatusOK {
+		t.Errorf("un-prefixed signed message: status = %d, body = %s", rec.Code, rec.Body)
+	}
+}
+
+func TestMessageHandlerSignature(t *testing.T) {
+	key := []byte("shared-secret")
+	cfg := DefaultConfig()
+	cfg.Secret = key
+	srv := NewServer(cfg, WithLogOutput(io.Discard))
+
+	encode := func(msg ServiceMessage) string {
+		b, err := json.Marshal(msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+
+	if rec := postMessage(t, srv, encode(signedMessage(key))); rec.Code != http.StatusOK {
+		t.Errorf("signed message: status = %d, body = %s", rec.Code, rec.Body)
+	}
+	tampered := signedMessage(key)
+	tampered.Payload = json.RawMessage(`{"op":"drop-tables"}`)
+	if rec := postMessage(t, srv, encode(tampered)); rec.Code != http.StatusUnauthorized {
+		t.Errorf("tampered payload: status = %d, want 401", rec.Code)
+	}
+	if rec := postMessage(t, srv, encode(signedMessage([]byte("other-key")))); rec.Code != http.StatusUnauthorized {
+		t.Errorf("wrong key: status = %d, want 401", rec.Code)
+	}
+	unsigned := signedMessage(key)
+	unsigned.Signature = ""
+	if rec := postMessage(t, srv, encode(unsigned)); rec.Code != http.StatusUnauthorized {
+		t.Errorf("unsigned: status = %d, want 401", rec.Code)
+	}
+
+	// Without a secret, signatures are not required.
+	if rec := postMessage(t, newTestServer(), encode(unsigned)); rec.Code != http.StatusOK {
+		t.Errorf("no secret configured: status = %d", rec.Code)
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/obinexus/rust-semverx/MVP/examples/drivers/semverx"
+)
+
+func newStabilityServer(floor semverx.Channel) *Server {
+	cfg := DefaultConfig()
+	cfg.Version = semverx.MustParseVersion("v1.stable.0.alpha.0.stable")
+	cfg.Supported = []semverx.Version{
+		semverx.MustParseVersion("v1.stable.0.stable.0.stable"),
+		semverx.MustParseVersion("v1.stable.1.rc.0.stable"),
+		semverx.MustParseVersion("v1.stable.2.stable.0.beta"),
+	}
+	cfg.Stability = semverx.StabilityPolicy{MinChannel: floor}
+	return NewServer(cfg, WithLogOutput(io.Discard))
+}
+
+func TestMessageStabilityPolicy(t *testing.T) {
+	tests := []struct {
+		version   string
+		status    int
+		component string
+	}{
+		{"v1.stable.0.stable.0.stable", http.StatusOK, ""},
+		{"v1.stable.0.rc.0.stable", http.StatusOK, ""},
+		{"v1.rc.0.rc.0.rc", http.StatusOK, ""},
+		{"v1.stable.0.beta.0.stable", http.StatusForbidden, "minor"},
+		{"v1.stable.0.stable.0.alpha", http.StatusForbidden, "patch"},
+		{"v1.beta.0.stable.0.stable", http.StatusForbidden, "major"},
+	}
+	for _, tt := range tests {
+		srv := newStabilityServer(semverx.ChannelRC)
+		rec := postMessage(t, srv, `{"service_id":"peer","version":"`+tt.version+`","timestamp":1}`)
+		if rec.Code != tt.status {
+			t.Errorf("%s: status = %d, want %d (%s)", tt.version, rec.Code, tt.status, rec.Body)
+			continue
+		}
+		if tt.component == "" {
+			continue
+		}
+		var body map[string]string
+		json.NewDecoder(rec.Body).Decode(&body)
+		if body["component"] != tt.component || body["min_channel"] != "rc" {
+			t.Errorf("%s: body = %v", tt.version, body)
+		}
+	}
+
+	srv := newStabilityServer(semverx.ChannelUnknown)
+	if rec := postMessage(t, srv, `{"service_id":"peer","version":"v1.stable.0.alpha.0.stable","timestamp":1}`); rec.Code != http.StatusOK {
+		t.Errorf("no floor: status = %d", rec.Code)
+	}
+}
+
+func TestNegotiateStabilityPolicy(t *testing.T) {
+	srv := newStabilityServer(semverx.ChannelRC)
+	rec := negotiate(t, srv, `{"service_id":"peer","constraint":">=v1.stable.0.stable.0.stable"}`)
+	var resp NegotiateResponse
+	json.NewDecoder(rec.Body).Decode(&resp)
+	if rec.Code != http.StatusOK || resp.Version != "v1.stable.1.rc.0.stable" {
+		t.Errorf("status = %d, version = %q; want the newest version at or above rc", rec.Code, resp.Version)
+	}
+
+	rec = negotiate(t, srv, `{"service_id":"peer","constraint":"~v1.stable.2.stable.0.beta"}`)
+	if rec.Code != http.StatusNotAcceptable {
+		t.Fatalf("only-beta match: status = %d, want 406", rec.Code)
+	}
+	var body map[string]string
+	json.NewDecoder(rec.Body).Decode(&body)
+	if body["component"] != "patch" || body["channel"] != "beta" {
+		t.Errorf("body = %v", body)
+	}
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/obinexus/rust-semverx/MVP/examples/drivers/semverx"
+)
+
+// Store persists peer state across restarts. The registry calls Save and
+// Delete as peers change, while holding its own lock, so implementations
+// should be quick; Load is called once when the registry is attached. It
+// must be safe for concurrent use.
+type Store interface {
+	Save(p Peer) error
+	Load() ([]Peer, error)
+	Delete(id string) error
+}
+
+// MemoryStore is a Store that keeps peers only for the life of the
+// process. It is what a registry uses when no other store is attached.
+type MemoryStore struct {
+	mu    sync.Mutex
+	peers map[string]Peer
+}
+
+// NewMemoryStore returns an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{peers: make(map[string]Peer)}
+}
+
+func (m *MemoryStore) Save(p Peer) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.peers[p.ServiceID] = p
+	return nil
+}
+
+func (m *MemoryStore) Delete(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.peers, id)
+	return nil
+}
+
+// Load returns the stored peers sorted by service ID.
+func (m *MemoryStore) Load() ([]Peer, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return sortedPeers(m.peers), nil
+}
+
+// FileStore is a Store that snapshots peers to a JSON file. Save and
+// Delete only change the in-memory state; Flush writes it out, replacing
+// the file atomically so a crash leaves either the old or the new
+// snapshot.
+type FileStore struct {
+	path string
+
+	// flushMu serializes Flush so snapshots reach the file in order.
+	flushMu sync.Mutex
+
+	mu    sync.Mutex
+	peers map[string]Peer
+	dirty bool
+}
+
+// NewFileStore returns a store snapshotting to path. Nothing is read until
+// Load.
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path, peers: make(map[string]Peer)}
+}
+
+// peerRecord is the snapshot form of a Peer. Versions are kept as strings
+// and read back leniently, so a peer on channels this build does not know
+// survives a restart.
+type peerRecord struct {
+	ServiceID  string    `json:"service_id"`
+	Version    string    `json:"version"`
+	LastSeen   time.Time `json:"last_seen"`
+	Negotiated string    `json:"negotiated_version,omitempty"`
+}
+
+func (f *FileStore) Save(p Peer) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.peers[p.ServiceID] = p
+	f.dirty = true
+	return nil
+}
+
+func (f *FileStore) Delete(id string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.peers[id]; ok {
+		delete(f.peers, id)
+		f.dirty = true
+	}
+	return nil
+}
+
+// Load reads the snapshot file, replacing the in-memory state. A missing
+// file is an empty snapshot. A file that cannot be decoded is reported as
+// an error and otherwise ignored: the store starts empty, and the next
+// Flush overwrites it.
+func (f *FileStore) Load() ([]Peer, error) {
+	b, err := os.ReadFile(f.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var records []peerRecord
+	if err := json.Unmarshal(b, &records); err != nil {
+		return nil, fmt.Errorf("peer snapshot %s: %w", f.path, err)
+	}
+	peers := make(map[string]Peer, len(records))
+	for _, rec := range records {
+		p := Peer{ServiceID: rec.ServiceID, LastSeen: rec.LastSeen}
+		if p.Version, err = semverx.ParseLenient(rec.Version); err != nil {
+			return nil, fmt.Errorf("peer snapshot %s: peer %q: %w", f.path, rec.ServiceID, err)
+		}
+		if rec.Negotiated != "" {
+			v, err := semverx.ParseLenient(rec.Negotiated)
+			if err != nil {
+				return nil, fmt.Errorf("peer snapshot %s: peer %q: %w", f.path, rec.ServiceID, err)
+			}
+			p.Negotiated = &v
+		}
+		peers[p.ServiceID] = p
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.peers, f.dirty = peers, false
+	return sortedPeers(peers), nil
+}
+
+// Flush writes the snapshot if anything changed since the last one.
+func (f *FileStore) Flush() error {
+	f.flushMu.Lock()
+	defer f.flushMu.Unlock()
+
+	f.mu.Lock()
+	if !f.dirty {
+		f.mu.Unlock()
+		return nil
+	}
+	peers := sortedPeers(f.peers)
+	f.dirty = false
+	f.mu.Unlock()
+
+	records := make([]peerRecord, len(peers))
+	for i, p := range peers {
+		records[i] = peerRecord{ServiceID: p.ServiceID, Version: p.Version.String(), LastSeen: p.LastSeen}
+		if p.Negotiated != nil {
+			records[i].Negotiated = p.Negotiated.String()
+		}
+	}
+	err := writeFileAtomic(f.path, records)
+	if err != nil {
+		f.mu.Lock()
+		f.dirty = true
+		f.mu.Unlock()
+	}
+	return err
+}
+
+// writeFileAtomic writes v as JSON to a temporary file beside path and
+// renames it into place.
+func writeFileAtomic(path string, v any) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(b, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func sortedPeers(m map[string]Peer) []Peer {
+	out := make([]Peer, 0, len(m))
+	for _, p := range m {
+		out = append(out, p)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ServiceID < out[j].ServiceID })
+	return out
+}
+
+// snapshotPeers flushes the peer store every PeerSnapshotInterval until the
+// returned function is called, which stops the loop and flushes one last
+// time. It does nothing without a peer store.
+func (s *Server) snapshotPeers() (stop func()) {
+	if s.peerStore == nil {
+		return func() {}
+	}
+	flush := func() {
+		if err := s.peerStore.Flush(); err != nil {
+			s.log.Warn("peer snapshot failed", "path", s.cfg.PeerStore, "err", err)
+		}
+	}
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		t := time.NewTicker(s.cfg.PeerSnapshotInterval)
+		defer t.Stop()
+		for {
+			select {
+			case <-t.C:
+				flush()
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		wg.Wait()
+		flush()
+	}
+}
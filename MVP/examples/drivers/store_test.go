@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/obinexus/rust-semverx/MVP/examples/drivers/semverx"
+)
+
+func TestFileStoreRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "peers.json")
+	seen := time.Unix(1700000000, 0).UTC()
+	negotiated := semverx.MustParseVersion("v1.stable.0.stable.0.stable")
+	degraded, err := semverx.ParseLenient("v1.stable.2.nightly.0.stable")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Peer{
+		{ServiceID: "python-service", Version: degraded, LastSeen: seen},
+		{ServiceID: "rust-service", Version: semverx.MustParseVersion("v1.stable.1.beta2.0.stable"), LastSeen: seen, Negotiated: &negotiated},
+	}
+
+	fs := NewFileStore(path)
+	for _, p := range want {
+		fs.Save(p)
+	}
+	fs.Save(Peer{ServiceID: "gone", Version: negotiated, LastSeen: seen})
+	fs.Delete("gone")
+	if err := fs.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := NewFileStore(path).Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Load() = %+v, want %+v", got, want)
+	}
+}
+
+func TestFileStoreTruncated(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "peers.json")
+	fs := NewFileStore(path)
+	fs.Save(Peer{ServiceID: "rust-service", Version: semverx.MustParseVersion("v1.stable.0.stable.0.stable"), LastSeen: time.Now()})
+	if err := fs.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, b[:len(b)/2], 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewPeerRegistry(time.Minute)
+	if err := r.UseStore(NewFileStore(path), nil); err == nil {
+		t.Fatal("UseStore accepted a truncated snapshot")
+	}
+	if peers := r.List(); len(peers) != 0 {
+		t.Errorf("registry rehydrated from a truncated snapshot: %+v", peers)
+	}
+
+	// The store stays attached, and the next snapshot replaces the bad file.
+	r.Register("python-service", semverx.MustParseVersion("v1.stable.0.stable.0.stable"))
+	if err := r.store.(*FileStore).Flush(); err != nil {
+		t.Fatal(err)
+	}
+	got, err := NewFileStore(path).Load()
+	if err != nil || len(got) != 1 || got[0].ServiceID != "python-service" {
+		t.Errorf("Load() after recovery = %+v, %v", got, err)
+	}
+}
+
+func TestPeerRegistryUseStore(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	v := semverx.MustParseVersion("v1.stable.0.stable.0.stable")
+	st := NewMemoryStore()
+	st.Save(Peer{ServiceID: "fresh", Version: v, LastSeen: now.Add(-30 * time.Second)})
+	st.Save(Peer{ServiceID: "stale", Version: v, LastSeen: now.Add(-time.Hour)})
+
+	r := NewPeerRegistry(time.Minute)
+	r.now = func() time.Time { return now }
+	if err := r.UseStore(st, nil); err != nil {
+		t.Fatal(err)
+	}
+	if list := r.List(); len(list) != 1 || list[0].ServiceID != "fresh" {
+		t.Errorf("List() after rehydrating = %+v", list)
+	}
+
+	r.Register("new", v)
+	r.Remove("fresh")
+	stored, _ := st.Load()
+	if len(stored) != 1 || stored[0].ServiceID != "new" {
+		t.Errorf("store = %+v, want only the new peer", stored)
+	}
+}
+
+func TestServePersistsPeers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "peers.json")
+	if err := os.WriteFile(path, []byte(`[{"service_id":`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := DefaultConfig()
+	cfg.PeerStore = path
+	cfg.ShutdownGrace = time.Second
+
+	srv := NewServer(cfg, WithLogOutput(io.Discard))
+	srv.peers.Register("rust-service", semverx.MustParseVersion("v1.stable.0.stable.0.stable"))
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := srv.Serve(ctx, ln); err != nil {
+		t.Fatal(err)
+	}
+
+	if list := NewServer(cfg, WithLogOutput(io.Discard)).peers.List(); len(list) != 1 || list[0].ServiceID != "rust-service" {
+		t.Errorf("peers after restart = %+v", list)
+	}
+}
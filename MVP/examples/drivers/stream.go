@@ -0,0 +1,285 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/obinexus/rust-semverx/MVP/examples/drivers/semverxpb"
+)
+
+const (
+	// streamCloseTimeout is how long a closing stream waits for the peer
+	// to answer the close frame.
+	streamCloseTimeout = 5 * time.Second
+	// streamWriteTimeout bounds each frame write so a stalled peer cannot
+	// hold the write lock forever.
+	streamWriteTimeout = 10 * time.Second
+	// streamMaxMessage caps streamed messages when MaxBodyBytes is 0.
+	streamMaxMessage = 32 << 20
+)
+
+// StreamEvent is a frame sent to /stream clients. Acks and errors answer
+// the client's own messages in the order they were sent.
+type StreamEvent struct {
+	// Type is "message" for a message accepted from another sender, "ack"
+	// when the client's message was accepted and "error" when it was not.
+	Type    string            `json:"type"`
+	Message *ServiceMessage   `json:"message,omitempty"`
+	Error   map[string]string `json:"error,omitempty"`
+}
+
+// stream is one /stream connection. Its sender is fixed by the X-Service-ID
+// header of the upgrade request or, failing that, by the first accepted
+// message. The header is only a claim: nothing is relayed to the
+// connection until one of its messages has been accepted, signature
+// included when the node has a secret.
+type stream struct {
+	conn    *websocket.Conn
+	writeMu sync.Mutex // the connection allows one writer at a time
+
+	mu    sync.Mutex
+	id    string
+	ready chan struct{} // closed by the first accepted message
+	once  sync.Once
+}
+
+func (st *stream) sender() string {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return st.id
+}
+
+// bind fixes the sender to id. It reports false, changing nothing, when
+// the sender is already another ID.
+func (st *stream) bind(id string) bool {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if st.id == "" {
+		st.id = id
+	}
+	return st.id == id
+}
+
+func (st *stream) unbind() {
+	st.mu.Lock()
+	st.id = ""
+	st.mu.Unlock()
+}
+
+func (st *stream) authenticate() { st.once.Do(func() { close(st.ready) }) }
+
+func (st *stream) authenticated() bool {
+	select {
+	case <-st.ready:
+		return true
+	default:
+		return false
+	}
+}
+
+func (st *stream) send(ev StreamEvent) error {
+	b, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	st.writeMu.Lock()
+	defer st.writeMu.Unlock()
+	st.conn.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+	return st.conn.WriteMessage(websocket.TextMessage, b)
+}
+
+// control writes a ping or close frame. Control frames may be written
+// concurrently with send.
+func (st *stream) control(kind int, data []byte) error {
+	return st.conn.WriteControl(kind, data, time.Now().Add(streamWriteTimeout))
+}
+
+// streamHandler upgrades to a WebSocket on which the peer sends messages,
+// as JSON text frames or protobuf binary frames, and receives every message
+// the node accepts from other senders once one of its own has been
+// accepted. Sent messages go through the same rate limit, replay protection
+// and checks as /message, and are capped at MaxBodyBytes. Browsers may only
+// connect from their own origin or one in AllowedOrigins. An authenticated
+// peer is removed from the registry when the connection ends.
+func (s *Server) streamHandler(w http.ResponseWriter, r *http.Request) {
+	up := websocket.Upgrader{
+		CheckOrigin: s.streamOrigin,
+		Error: func(w http.ResponseWriter, r *http.Request, status int, reason error) {
+			writeError(w, status, reason.Error())
+		},
+	}
+	conn, err := up.Upgrade(w, r, nil)
+	if err != nil {
+		s.log.Debug("stream upgrade failed", "origin", r.Header.Get("Origin"), "err", err)
+		return
+	}
+	defer conn.Close()
+	// The hijacked connection keeps the deadlines the server set for the
+	// upgrade request.
+	conn.NetConn().SetDeadline(time.Time{})
+	limit := s.cfg.MaxBodyBytes
+	if limit <= 0 {
+		limit = streamMaxMessage
+	}
+	conn.SetReadLimit(limit)
+	st := &stream{conn: conn, id: r.Header.Get(serviceIDHeader), ready: make(chan struct{})}
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		s.streamWriter(st, done)
+	}()
+
+	if s.cfg.StreamPing > 0 {
+		extend := func(string) error { return conn.SetReadDeadline(time.Now().Add(2 * s.cfg.StreamPing)) }
+		extend("")
+		conn.SetPongHandler(extend)
+	}
+	s.streamReader(r.Context(), st)
+
+	close(done)
+	wg.Wait()
+	if id := st.sender(); id != "" && st.authenticated() {
+		setSender(r, id)
+		s.peers.Remove(id)
+	}
+}
+
+// streamReader handles the peer's messages until the connection ends.
+func (s *Server) streamReader(ctx context.Context, st *stream) {
+	for {
+		op, data, err := st.conn.ReadMessage()
+		if err != nil {
+			var ce *websocket.CloseError
+			if !errors.As(err, &ce) {
+				s.log.Debug("stream read failed", "sender", st.sender(), "err", err)
+			}
+			return
+		}
+		if s.cfg.StreamPing > 0 {
+			st.conn.SetReadDeadline(time.Now().Add(2 * s.cfg.StreamPing))
+		}
+		contentType := "application/json"
+		if op == websocket.BinaryMessage {
+			contentType = semverxpb.ContentType
+		}
+		ev := StreamEvent{Type: "ack"}
+		if merr := s.acceptStreamed(ctx, st, contentType, data); merr != nil {
+			ev = StreamEvent{Type: "error", Error: merr.fields}
+		}
+		if err := st.send(ev); err != nil {
+			return
+		}
+	}
+}
+
+// acceptStreamed applies to one streamed message what the /message
+// middleware and handler apply to a request.
+func (s *Server) acceptStreamed(ctx context.Context, st *stream, contentType string, data []byte) (merr *messageError) {
+	msg, err := decodeServiceMessage(contentType, data)
+	if err != nil {
+		s.metrics.MessageRejected("malformed")
+		return rejectInvalid(http.StatusBadRequest, "invalid message body: "+err.Error(), err)
+	}
+	if s.limiter != nil {
+		if ok, _ := s.limiter.allow(msg.ServiceID); !ok {
+			s.metrics.MessageRejected("rate_limited")
+			return rejectMessage(http.StatusTooManyRequests, "rate limit exceeded")
+		}
+	}
+	if s.replay != nil {
+		key, err := s.replay.reserve(msg)
+		if err != nil {
+			s.metrics.MessageRejected("replay")
+			return replayRejection(err)
+		}
+		defer func() {
+			if merr != nil {
+				s.replay.release(key)
+			}
+		}()
+	}
+	// Bind before accepting so the writer already skips the message when
+	// it comes back from the bus.
+	first := st.sender() == ""
+	if !st.bind(msg.ServiceID) {
+		s.metrics.MessageRejected("invalid")
+		return rejectMessage(http.StatusBadRequest, "service_id does not match the stream's sender "+st.sender())
+	}
+	merr = s.acceptMessage(ctx, msg)
+	if merr != nil {
+		if first {
+			st.unbind()
+		}
+		return merr
+	}
+	st.authenticate()
+	return nil
+}
+
+// streamWriter keeps the connection alive until done is closed or the
+// server shuts down and, once the stream is authenticated, relays bus
+// messages from other senders.
+func (s *Server) streamWriter(st *stream, done <-chan struct{}) {
+	var msgs <-chan ServiceMessage
+	ready := st.ready
+	var ping <-chan time.Time
+	if s.cfg.StreamPing > 0 {
+		t := time.NewTicker(s.cfg.StreamPing)
+		defer t.Stop()
+		ping = t.C
+	}
+	for {
+		select {
+		case <-ready:
+			var unsubscribe func()
+			msgs, unsubscribe = s.bus.Subscribe(BusFilter{})
+			defer unsubscribe()
+			ready = nil
+		case msg, ok := <-msgs:
+			if !ok {
+				return
+			}
+			if msg.ServiceID == st.sender() {
+				continue
+			}
+			if err := st.send(StreamEvent{Type: "message", Message: &msg}); err != nil {
+				return
+			}
+		case <-ping:
+			if err := st.control(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		case <-s.shutdown:
+			st.control(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down"))
+			st.conn.SetReadDeadline(time.Now().Add(streamCloseTimeout))
+			return
+		case <-done:
+			return
+		}
+	}
+}
+
+// streamOrigin is the stream Upgrader's CheckOrigin. Requests without an
+// Origin header do not come from a browser and may connect.
+func (s *Server) streamOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	return origin == "" || sameOrigin(origin, r.Host) || s.cfg.CORS.allowOrigin(origin) != ""
+}
+
+// sameOrigin reports whether origin names host, as a browser's Origin does
+// for a page served by this node.
+func sameOrigin(origin, host string) bool {
+	u, err := url.Parse(origin)
+	return err == nil && u.Host != "" && strings.EqualFold(u.Host, host)
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/obinexus/rust-semverx/MVP/examples/drivers/semverx"
+)
+
+func streamURL(ts *httptest.Server) string {
+	return "ws" + strings.TrimPrefix(ts.URL, "http") + "/stream"
+}
+
+func dialStream(t *testing.T, ts *httptest.Server, sender string) *websocket.Conn {
+	t.Helper()
+	h := http.Header{}
+	if sender != "" {
+		h.Set("X-Service-ID", sender)
+	}
+	c, _, err := websocket.DefaultDialer.Dial(streamURL(ts), h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetReadDeadline(time.Now().Add(5 * time.Second))
+	return c
+}
+
+func readEvent(t *testing.T, c *websocket.Conn) StreamEvent {
+	t.Helper()
+	_, data, err := c.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ev StreamEvent
+	if err := json.Unmarshal(data, &ev); err != nil {
+		t.Fatal(err)
+	}
+	return ev
+}
+
+func TestStreamBroadcast(t *testing.T) {
+	srv := newTestServer()
+	ts := httptest.NewServer(srv.Routes())
+	defer ts.Close()
+
+	listener := dialStream(t, ts, "python-service")
+	defer listener.Close()
+	listener.WriteMessage(websocket.TextMessage, []byte(`{"service_id":"python-service","version":"v1.stable.0.stable.0.stable","timestamp":1}`))
+	if ev := readEvent(t, listener); ev.Type != "ack" {
+		t.Fatalf("listener's message answered with %+v", ev)
+	}
+	sender := dialStream(t, ts, "")
+	defer sender.Close()
+
+	sender.WriteMessage(websocket.TextMessage, []byte(`{"service_id":"rust-service","version":"v2.stable.0.stable.0.stable","timestamp":1}`))
+	if ev := readEvent(t, sender); ev.Type != "error" || ev.Error["error"] != "incompatible version" {
+		t.Errorf("incompatible message answered with %+v", ev)
+	}
+	sender.WriteMessage(websocket.TextMessage, []byte(`{"service_id":"rust-service","version":"v1.stable.0.stable.0.stable","timestamp":1}`))
+	if ev := readEvent(t, sender); ev.Type != "ack" {
+		t.Errorf("accepted message answered with %+v", ev)
+	}
+	ev := readEvent(t, listener)
+	if ev.Type != "message" || ev.Message == nil || ev.Message.ServiceID != "rust-service" {
+		t.Fatalf("listener received %+v", ev)
+	}
+	if _, ok := srv.peers.Lookup("rust-service"); !ok {
+		t.Fatal("streamed sender not registered")
+	}
+
+	sender.WriteMessage(websocket.TextMessage, []byte(`{"service_id":"other","version":"v1.stable.0.stable.0.stable","timestamp":2}`))
+	if ev := readEvent(t, sender); ev.Type != "error" {
+		t.Errorf("message from a second sender answered with %+v", ev)
+	}
+
+	sender.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+	if _, _, err := sender.ReadMessage(); err == nil {
+		t.Fatal("stream still open after close")
+	}
+	deadline := time.Now().Add(time.Second)
+	for {
+		if _, ok := srv.peers.Lookup("rust-service"); !ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("peer still registered after disconnect")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestStreamOrigin(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.CORS.AllowedOrigins = []string{"https://dash.example"}
+	ts := httptest.NewServer(NewServer(cfg, WithLogOutput(io.Discard)).Routes())
+	defer ts.Close()
+
+	for origin, ok := range map[string]bool{
+		"":                     true,
+		"https://dash.example": true,
+		ts.URL:                 true,
+		"https://evil.example": false,
+		"null":                 false,
+	} {
+		h := http.Header{}
+		if origin != "" {
+			h.Set("Origin", origin)
+		}
+		c, resp, err := websocket.DefaultDialer.Dial(streamURL(ts), h)
+		if err == nil {
+			c.Close()
+		}
+		if (err == nil) != ok {
+			t.Errorf("origin %q: err = %v, want allowed %v", origin, err, ok)
+		}
+		if !ok && (resp == nil || resp.StatusCode != http.StatusForbidden) {
+			t.Errorf("origin %q: response %v, want 403", origin, resp)
+		}
+	}
+}
+
+func TestStreamReadLimit(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.MaxBodyBytes = 64
+	ts := httptest.NewServer(NewServer(cfg, WithLogOutput(io.Discard)).Routes())
+	defer ts.Close()
+	c := dialStream(t, ts, "")
+	defer c.Close()
+
+	c.WriteMessage(websocket.TextMessage, []byte(`{"service_id":"rust-service","payload":"`+strings.Repeat("x", 100)+`"}`))
+	_, _, err := c.ReadMessage()
+	if !websocket.IsCloseError(err, websocket.CloseMessageTooBig) {
+		t.Errorf("oversized message: err = %v, want close %d", err, websocket.CloseMessageTooBig)
+	}
+}
+
+func TestStreamRelaysOnlyAuthenticated(t *testing.T) {
+	key := []byte("shared-secret")
+	cfg := DefaultConfig()
+	cfg.Secret = key
+	srv := NewServer(cfg, WithLogOutput(io.Discard))
+	ts := httptest.NewServer(srv.Routes())
+	defer ts.Close()
+	send := func(c *websocket.Conn, msg ServiceMessage) StreamEvent {
+		t.Helper()
+		b, _ := json.Marshal(msg)
+		c.WriteMessage(websocket.TextMessage, b)
+		return readEvent(t, c)
+	}
+	msg := func(id string, ts int64) ServiceMessage {
+		m := ServiceMessage{ServiceID: id, Version: semverx.MustParseVersion("v1.stable.0.stable.0.stable"), Payload: json.RawMessage(`{}`), Timestamp: ts}
+		SignMessage(&m, key)
+		return m
+	}
+
+	sender := dialStream(t, ts, "")
+	defer sender.Close()
+	if ev := send(sender, msg("rust-service", 1)); ev.Type != "ack" {
+		t.Fatalf("signed message answered with %+v", ev)
+	}
+
+	// Claiming a sender in the header proves nothing.
+	listener := dialStream(t, ts, "rust-service")
+	unsigned := msg("rust-service", 2)
+	unsigned.Signature = ""
+	if ev := send(listener, unsigned); ev.Type != "error" {
+		t.Fatalf("unsigned message answered with %+v", ev)
+	}
+	if ev := send(sender, msg("rust-service", 3)); ev.Type != "ack" {
+		t.Fatalf("signed message answered with %+v", ev)
+	}
+	listener.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+	if _, data, err := listener.ReadMessage(); err == nil {
+		t.Errorf("unauthenticated stream received %s", data)
+	}
+	listener.Close()
+
+	listener = dialStream(t, ts, "")
+	defer listener.Close()
+	if ev := send(listener, msg("python-service", 4)); ev.Type != "ack" {
+		t.Fatalf("listener's signed message answered with %+v", ev)
+	}
+	if ev := readEvent(t, sender); ev.Type != "message" || ev.Message.ServiceID != "python-service" {
+		t.Errorf("sender received %+v", ev)
+	}
+	if ev := send(sender, msg("rust-service", 5)); ev.Type != "ack" {
+		t.Fatalf("signed message answered with %+v", ev)
+	}
+	if ev := readEvent(t, listener); ev.Type != "message" || ev.Message.ServiceID != "rust-service" {
+		t.Errorf("authenticated listener received %+v", ev)
+	}
+	if _, ok := srv.peers.Lookup("rust-service"); !ok {
+		t.Error("unauthenticated stream removed the peer it claimed")
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/obinexus/rust-semverx/MVP/examples/drivers"
+
+// Span attributes added on top of the OpenTelemetry HTTP conventions.
+const (
+	attrSender  = attribute.Key("semverx.sender")
+	attrVersion = attribute.Key("semverx.version")
+	attrChannel = attribute.Key("semverx.channel")
+	attrPeer    = attribute.Key("semverx.peer")
+)
+
+// traceContext reads and writes W3C traceparent and tracestate headers.
+var traceContext = propagation.TraceContext{}
+
+// setupTracing installs a global tracer provider exporting over OTLP/HTTP
+// when OTEL_EXPORTER_OTLP_ENDPOINT or OTEL_EXPORTER_OTLP_TRACES_ENDPOINT is
+// set; the exporter reads the rest of its settings from the standard OTEL_*
+// variables. Otherwise the global provider stays a no-op. The returned
+// function flushes and stops the exporter.
+func setupTracing(ctx context.Context, cfg Config, getenv func(string) string) (func(context.Context) error, error) {
+	if getenv("OTEL_EXPORTER_OTLP_ENDPOINT") == "" && getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+	exp, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exp),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", cfg.ServiceID))),
+	)
+	otel.SetTracerProvider(tp)
+	return tp.Shutdown, nil
+}
+
+// SetTracerProvider makes the server and its forwarder create spans
+// through tp instead of the global provider.
+func (s *Server) SetTracerProvider(tp trace.TracerProvider) {
+	s.tracer = tp.Tracer(tracerName)
+	s.forwarder.Tracer = s.tracer
+}
+
+// traced runs h in a server span named after route, continuing the trace of
+// an incoming traceparent header.
+func (s *Server) traced(route string, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := traceContext.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := s.tracer.Start(ctx, r.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", r.Method),
+				attribute.String("http.route", route),
+				attribute.String("url.path", r.URL.Path),
+			))
+		defer span.End()
+
+		rec := &statusRecorder{ResponseWriter: w}
+		h.ServeHTTP(rec, r.WithContext(ctx))
+		if rec.status == 0 {
+			rec.status = http.StatusOK
+		}
+		span.SetAttributes(attribute.Int("http.response.status_code", rec.status))
+		if rec.status >= 500 {
+			span.SetStatus(codes.Error, strconv.Itoa(rec.status))
+		}
+	})
+}
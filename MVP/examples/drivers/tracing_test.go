@@ -0,0 +1,85 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/obinexus/rust-semverx/MVP/examples/drivers/semverx"
+)
+
+func TestTracingSpans(t *testing.T) {
+	rec := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec))
+
+	peerCfg := DefaultConfig()
+	peerCfg.ServiceID = "peer-service"
+	peer := NewServer(peerCfg, WithLogOutput(io.Discard))
+	peer.SetTracerProvider(tp)
+	peerTS := httptest.NewServer(peer.Routes())
+	defer peerTS.Close()
+
+	cfg := DefaultConfig()
+	cfg.PeerURLs = map[string]string{"peer-service": peerTS.URL}
+	srv := NewServer(cfg, WithLogOutput(io.Discard))
+	srv.SetTracerProvider(tp)
+	srv.peers.Register("peer-service", semverx.MustParseVersion("v1.stable.0.stable.0.stable"))
+
+	const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	req := newMessageRequest("/message?forward=true", `{"service_id":"rust-service","version":"v1.stable.1.stable.0.stable","timestamp":1}`)
+	req.Header.Set("traceparent", traceparent)
+	w := httptest.NewRecorder()
+	srv.Routes().ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", w.Code, w.Body)
+	}
+
+	// Spans end innermost first: the peer's handler, the relay, then ours.
+	spans := rec.Ended()
+	if len(spans) != 3 {
+		t.Fatalf("recorded %d spans, want 3", len(spans))
+	}
+	peerSpan, fwd, root := spans[0], spans[1], spans[2]
+	for _, c := range []struct {
+		span sdktrace.ReadOnlySpan
+		name string
+		kind trace.SpanKind
+	}{{root, "POST /message", trace.SpanKindServer}, {fwd, "forward", trace.SpanKindClient}, {peerSpan, "POST /message", trace.SpanKindServer}} {
+		if c.span.Name() != c.name || c.span.SpanKind() != c.kind {
+			t.Errorf("span %q kind %s, want %q kind %s", c.span.Name(), c.span.SpanKind(), c.name, c.kind)
+		}
+		if got := c.span.SpanContext().TraceID().String(); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+			t.Errorf("span %q has trace ID %s, want the incoming one", c.span.Name(), got)
+		}
+	}
+	if got := root.Parent().SpanID().String(); got != "00f067aa0ba902b7" || !root.Parent().IsRemote() {
+		t.Errorf("root parent = %s, want the remote traceparent span", got)
+	}
+	if fwd.Parent().SpanID() != root.SpanContext().SpanID() {
+		t.Error("forward span is not a child of the request span")
+	}
+	if peerSpan.Parent().SpanID() != fwd.SpanContext().SpanID() {
+		t.Error("peer request span is not a child of the forward span")
+	}
+
+	attrs := map[string]string{}
+	for _, kv := range root.Attributes() {
+		attrs[string(kv.Key)] = kv.Value.Emit()
+	}
+	if attrs["semverx.sender"] != "rust-service" || attrs["semverx.channel"] != "stable" || attrs["http.response.status_code"] != "200" {
+		t.Errorf("root attributes = %v", attrs)
+	}
+}
+
+func TestTracingNoopByDefault(t *testing.T) {
+	_, span := newTestServer().tracer.Start(t.Context(), "x")
+	defer span.End()
+	if span.IsRecording() {
+		t.Error("tracer records spans without a configured provider")
+	}
+}